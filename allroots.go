@@ -0,0 +1,162 @@
+package msa
+
+import (
+	"fmt"
+	"github.com/gyuho/goraph"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// SolveAllRoots finds the root whose Minimum Spanning Arborescence is the lightest (or heaviest with WithObjective(Maximize)), and returns that arborescence.
+// Rather than solving once per node, it adds an artificial root linked to every node by an edge heavier than any arborescence,
+// so that a single solve picks the best real root. If no node can reach every other one, ErrNoRoot is returned.
+// g isn't modified.
+func SolveAllRoots(g goraph.Graph, opts ...Option) (*Arborescence, error) {
+	arb, err := solveAllRoots(Goraph(g), opts)
+	if err != nil && err != ErrNoRoot {
+		return nil, fmt.Errorf("SolveAllRoots: %v", err)
+	}
+	return arb, err
+}
+
+// SolveAllRootsGraph is like SolveAllRoots, but works on any Graph
+func SolveAllRootsGraph(g Graph, opts ...Option) (*Arborescence, error) {
+	arb, err := solveAllRoots(g, opts)
+	if err != nil && err != ErrNoRoot {
+		return nil, fmt.Errorf("SolveAllRootsGraph: %v", err)
+	}
+	return arb, err
+}
+
+// solveAllRoots implements SolveAllRoots and SolveAllRootsGraph
+func solveAllRoots(g Graph, opts []Option) (*Arborescence, error) {
+	c := newConfig(opts)
+	if len(g.Nodes()) == 0 {
+		return nil, ErrNoRoot
+	}
+
+	sg, err := newSuperRooted(g)
+	if err != nil {
+		return nil, err
+	}
+
+	// The artificial edges must be heavier than any difference between two arborescences of g, so that the best solution uses as few of them as possible
+	err = sg.setWeight(c.sign() * sg.heavy())
+	if err != nil {
+		return nil, err
+	}
+
+	roots, arb, err := sg.solve(opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(roots) != 1 {
+		return nil, ErrNoRoot
+	}
+	arb.Root = roots[0]
+	return arb, nil
+}
+
+// superRooted is a Graph adding to another one an artificial root linked to every node, without copying it
+type superRooted struct {
+	g      Graph
+	root   goraph.Node
+	nodes  []goraph.ID // the nodes of the original graph
+	span   float64     // sum of the absolute values of the weights of the original graph
+	weight float64     // weight of the edges going out of the artificial root
+}
+
+// newSuperRooted adds an artificial root to g, its edges having a weight of 0
+func newSuperRooted(g Graph) (*superRooted, error) {
+	sg := &superRooted{
+		g:     g,
+		nodes: g.Nodes(),
+	}
+	sg.root = goraph.NewNode(superRootName(sg.nodes))
+	for _, id := range sg.nodes {
+		edges, err := g.Incoming(id)
+		if err != nil {
+			return nil, fmt.Errorf("newSuperRooted: error while retrieving edges going into %s: %v", id.String(), err)
+		}
+		for _, e := range edges {
+			sg.span += math.Abs(e.Weight())
+		}
+	}
+	return sg, nil
+}
+
+// Nodes returns the IDs of every node of the original graph, and that of the artificial root
+func (sg *superRooted) Nodes() []goraph.ID {
+	ids := make([]goraph.ID, len(sg.nodes), len(sg.nodes)+1)
+	copy(ids, sg.nodes)
+	return append(ids, sg.root.ID())
+}
+
+// Incoming returns the edges going into the node with the given ID in the original graph, along with the one coming from the artificial root
+func (sg *superRooted) Incoming(id goraph.ID) ([]goraph.Edge, error) {
+	if id.String() == sg.root.ID().String() {
+		return nil, nil
+	}
+	edges, err := sg.g.Incoming(id)
+	if err != nil {
+		return nil, err
+	}
+	edges = edges[:len(edges):len(edges)] // so that appending to it copies it
+	return append(edges, goraph.NewEdge(sg.root, goraph.NewNode(id.String()), sg.weight)), nil
+}
+
+// heavy returns a weight larger than the difference between the weights of any two branchings of the original graph
+func (sg *superRooted) heavy() float64 {
+	return 2*sg.span + 1
+}
+
+// setWeight sets the weight of every edge going out of the artificial root
+func (sg *superRooted) setWeight(weight float64) error {
+	if math.IsInf(weight, 0) || math.IsNaN(weight) {
+		return fmt.Errorf("setWeight: weight %v of the artificial root's edges isn't a finite number", weight)
+	}
+	sg.weight = weight
+	return nil
+}
+
+// solve solves from the artificial root, returning the nodes linked to it, sorted, and the rest of the arborescence
+// The returned arborescence has no Root set
+func (sg *superRooted) solve(opts []Option) ([]goraph.ID, *Arborescence, error) {
+	arb, err := solve(sg, sg.root.ID(), newConfig(opts))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var roots []goraph.ID
+	res := &Arborescence{
+		Parent: make(map[goraph.ID]goraph.Edge, len(arb.Parent)),
+		Edges:  make([]goraph.Edge, 0, len(arb.Edges)),
+	}
+	for _, e := range arb.Edges {
+		if e.Source().ID().String() == sg.root.ID().String() {
+			roots = append(roots, e.Target().ID())
+			continue
+		}
+		res.Parent[e.Target().ID()] = e
+		res.Edges = append(res.Edges, e)
+		res.Weight += e.Weight()
+	}
+	sort.Sort(idsByString(roots))
+	return roots, res, nil
+}
+
+// superRootName returns the name of a node that isn't one of nodes
+func superRootName(nodes []goraph.ID) string {
+	taken := make(map[string]struct{}, len(nodes))
+	for _, id := range nodes {
+		taken[id.String()] = struct{}{}
+	}
+	name := "superroot"
+	for i := 0; ; i++ {
+		if _, ok := taken[name]; !ok {
+			return name
+		}
+		name = "superroot" + strconv.Itoa(i)
+	}
+}
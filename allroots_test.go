@@ -0,0 +1,63 @@
+package msa
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Test that SolveAllRoots finds the same weight as solving for every root
+func TestSolveAllRoots(t *testing.T) {
+	for i := 0; i <= 17; i++ {
+		graphID := fmt.Sprintf("graph_%02d", i)
+		g := loadGraph(t, graphID)
+		for _, obj := range []Objective{Minimize, Maximize} {
+			// Solve for every root
+			var best *Arborescence
+			for root := range g.GetNodes() {
+				arb, err := Solve(g, root, WithAlgorithm(Tarjan), WithObjective(obj))
+				if _, ok := err.(*InfeasibleError); ok {
+					continue
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if best == nil || (obj == Minimize && arb.Weight < best.Weight) || (obj == Maximize && arb.Weight > best.Weight) {
+					best = arb
+				}
+			}
+
+			for _, alg := range []Algorithm{Naive, Tarjan} {
+				arb, err := SolveAllRoots(g, WithAlgorithm(alg), WithObjective(obj))
+				if best == nil {
+					if err != ErrNoRoot {
+						t.Errorf("%s (%v, %v): expected ErrNoRoot, got %v", graphID, alg, obj, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("%s (%v, %v): unexpected error: %v", graphID, alg, obj, err)
+					continue
+				}
+				if arb.Weight != best.Weight {
+					t.Errorf("%s (%v, %v): expected weight %v, got %v rooted at %s", graphID, alg, obj, best.Weight, arb.Weight, arb.Root)
+				}
+				if len(arb.Parent) != g.GetNodeCount()-1 {
+					t.Errorf("%s (%v, %v): expected %d parents, got %d", graphID, alg, obj, g.GetNodeCount()-1, len(arb.Parent))
+				}
+				if _, ok := arb.Parent[arb.Root]; ok {
+					t.Errorf("%s (%v, %v): root %s has a parent", graphID, alg, obj, arb.Root)
+				}
+			}
+		}
+	}
+}
+
+func TestMSAAllRoots_Infeasible(t *testing.T) {
+	feasible, graph, rootID, err := MSAAllRoots(loadGraph(t, "graph_05"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feasible || graph != nil || rootID != nil {
+		t.Errorf("Expected graph_05 to be infeasible, got root %v", rootID)
+	}
+}
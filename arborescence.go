@@ -0,0 +1,115 @@
+package msa
+
+import (
+	"fmt"
+	"github.com/gyuho/goraph"
+)
+
+// Arborescence is a spanning arborescence of a graph, as returned by Solve
+type Arborescence struct {
+	// Root is the ID of the root of the arborescence
+	Root goraph.ID
+
+	// Parent maps every non-root node ID to the edge coming into it
+	Parent map[goraph.ID]goraph.Edge
+
+	// Weight is the total weight of the arborescence
+	Weight float64
+
+	// Edges lists the edges of the arborescence, as returned by the Incoming method of the Graph solved
+	// They tell apart parallel edges, such as the LabeledEdges or IdentifiedEdges of a Multigraph.
+	Edges []goraph.Edge
+
+	// Duals certifies that the arborescence is optimal, see Verify
+	// It is only filled by Solve when given WithDuals
+	Duals []Dual
+}
+
+// Graph returns the arborescence as a new goraph.Graph
+func (arb *Arborescence) Graph() (goraph.Graph, error) {
+	g := goraph.NewGraph()
+	g.AddNode(goraph.NewNode(arb.Root.String()))
+	for _, e := range arb.Edges {
+		g.AddNode(goraph.NewNode(e.Source().ID().String()))
+		g.AddNode(goraph.NewNode(e.Target().ID().String()))
+	}
+	for _, e := range arb.Edges {
+		err := g.AddEdge(goraph.StringID(e.Source().ID().String()), goraph.StringID(e.Target().ID().String()), e.Weight())
+		if err != nil {
+			return nil, fmt.Errorf("Graph: error while adding edge %s: %v", e.String(), err)
+		}
+	}
+	return g, nil
+}
+
+// Solve calculates the Minimum Spanning Arborescence of g rooted at root, or the maximum one with WithObjective(Maximize).
+// Unlike MSA, it doesn't modify g, so the same graph can be reused for several roots.
+// If some nodes can't be reached from root, the returned error is an *InfeasibleError listing them.
+// Edges can be required or forbidden with WithRequired and WithForbidden, a *ConstraintError being returned if they contradict each other.
+func Solve(g goraph.Graph, root goraph.ID, opts ...Option) (*Arborescence, error) {
+	if _, err := g.GetNode(root); err != nil {
+		return nil, fmt.Errorf("Solve: root %s isn't in the graph: %v", root.String(), err)
+	}
+	return solve(Goraph(g), root, newConfig(opts))
+}
+
+// SolveGraph is like Solve, but works on any Graph, such as an Adjacency or a gonum graph adapted by package msagonum.
+func SolveGraph(g Graph, root goraph.ID, opts ...Option) (*Arborescence, error) {
+	if !hasNode(g, root) {
+		return nil, fmt.Errorf("SolveGraph: root %s isn't in the graph", root.String())
+	}
+	return solve(g, root, newConfig(opts))
+}
+
+// solve implements Solve and SolveGraph, converting g once and running the selected algorithm on the result
+func solve(g Graph, root goraph.ID, c *config) (*Arborescence, error) {
+	switch c.objective {
+	case Minimize, Maximize:
+	default:
+		return nil, fmt.Errorf("solve: unknown objective %v", c.objective)
+	}
+	switch c.algorithm {
+	case Naive, Tarjan:
+	default:
+		return nil, fmt.Errorf("solve: unknown algorithm %v", c.algorithm)
+	}
+
+	d, r, err := newConstrainedDigraph(g, root, c)
+	switch err.(type) {
+	case nil:
+	case *InfeasibleError, *ConstraintError:
+		return nil, err
+	default:
+		return nil, fmt.Errorf("solve: %v", err)
+	}
+
+	d.scale(c.sign())
+	d.best()
+	var dt *dualTree
+	if c.duals {
+		dt = &dualTree{}
+	}
+	var (
+		in []int
+		ok bool
+	)
+	if c.algorithm == Naive {
+		in, ok = d.naive(r)
+		// The contraction doesn't keep track of the dual variables, so get them from the efficient algorithm, as they certify any optimal arborescence
+		if ok && dt != nil {
+			d.tarjan(r, dt)
+		}
+	} else {
+		in, ok = d.tarjan(r, dt)
+	}
+	if !ok {
+		return nil, fmt.Errorf("solve: no spanning arborescence is rooted at %s", root.String())
+	}
+	d.scale(c.sign())
+
+	arb := d.arborescence(r, in)
+	if dt != nil {
+		arb.Duals = dt.duals(d, r, c.sign())
+	}
+	return arb, nil
+}
@@ -0,0 +1,83 @@
+package msa
+
+import (
+	"github.com/gyuho/goraph"
+	"os"
+	"sort"
+	"testing"
+)
+
+// loadGraph loads the graph with the given ID from testdata/graph.json
+func loadGraph(t testing.TB, graphID string) goraph.Graph {
+	f, err := os.Open("testdata/graph.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	g, err := goraph.NewGraphFromJSON(f, graphID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// edgeStrings returns the sorted string representation of every edge of g
+func edgeStrings(t testing.TB, g goraph.Graph) []string {
+	edges, err := GetEdges(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	strs := make([]string, len(edges))
+	for i, e := range edges {
+		strs[i] = e.String()
+	}
+	sort.Strings(strs)
+	return strs
+}
+
+func TestSolve_NonDestructive(t *testing.T) {
+	g := loadGraph(t, "graph_17")
+	before := edgeStrings(t, g)
+
+	for _, root := range []string{"A", "B", "C", "D"} {
+		arb, err := Solve(g, goraph.StringID(root))
+		if err != nil {
+			t.Fatalf("Solve with root %s returned error: %v", root, err)
+		}
+
+		if arb.Root.String() != root {
+			t.Errorf("Root is %s, expected %s", arb.Root, root)
+		}
+		if len(arb.Parent) != g.GetNodeCount()-1 || len(arb.Edges) != len(arb.Parent) {
+			t.Errorf("Expected %d edges, got %d (%d parents)", g.GetNodeCount()-1, len(arb.Edges), len(arb.Parent))
+		}
+		var total float64
+		for _, e := range arb.Edges {
+			total += e.Weight()
+			if arb.Parent[e.Target().ID()] != e {
+				t.Errorf("Parent of %s isn't %s", e.Target(), e)
+			}
+		}
+		if total != arb.Weight {
+			t.Errorf("Weight is %v, but edges sum to %v", arb.Weight, total)
+		}
+
+		after := edgeStrings(t, g)
+		if len(after) != len(before) {
+			t.Fatalf("Solve modified its input: had %v, now %v", before, after)
+		}
+		for i := range before {
+			if before[i] != after[i] {
+				t.Fatalf("Solve modified its input: had %v, now %v", before, after)
+			}
+		}
+	}
+}
+
+func TestSolve_UnknownRoot(t *testing.T) {
+	g := loadGraph(t, "graph_17")
+	if _, err := Solve(g, goraph.StringID("Z")); err == nil {
+		t.Error("Expected an error for a root that isn't in the graph")
+	}
+}
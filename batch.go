@@ -0,0 +1,136 @@
+package msa
+
+import (
+	"fmt"
+	"github.com/gyuho/goraph"
+	"sync"
+)
+
+// Problem is one of the problems solved by SolveBatch and SolveStream, either a Graph or a weight matrix
+type Problem struct {
+	// Graph is solved by SolveGraph rooted at Root, if it isn't nil
+	Graph Graph
+	Root  goraph.ID
+
+	// Matrix is solved by MSAMatrix rooted at MatrixRoot otherwise
+	Matrix     [][]float64
+	MatrixRoot int
+}
+
+// Result is the solution of a Problem
+type Result struct {
+	// Index is the position of the problem in the input
+	Index int
+
+	// Arborescence is the solution of a Graph problem
+	Arborescence *Arborescence
+
+	// Heads is the solution of a Matrix problem
+	Heads []int
+
+	// Err is the error returned when solving the problem, if any
+	Err error
+}
+
+// solveProblem solves the problem at the given index
+func solveProblem(index int, p Problem, opts []Option) Result {
+	r := Result{Index: index}
+	switch {
+	case p.Graph != nil:
+		r.Arborescence, r.Err = SolveGraph(p.Graph, p.Root, opts...)
+	case p.Matrix != nil:
+		r.Heads, r.Err = MSAMatrix(p.Matrix, p.MatrixRoot, opts...)
+	default:
+		r.Err = fmt.Errorf("solveProblem: problem %d has neither a graph nor a matrix", index)
+	}
+	return r
+}
+
+// SolveBatch solves every problem concurrently over a pool of workers, whose size is set by WithWorkers, returning their results in the same order.
+// A problem failing doesn't prevent the others from being solved, its error being reported in its Result.
+// The other options apply to every problem.
+func SolveBatch(problems []Problem, opts ...Option) []Result {
+	results := make([]Result, len(problems))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < newConfig(opts).poolSize(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = solveProblem(i, problems[i], opts)
+			}
+		}()
+	}
+	for i := range problems {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+// SolveStream is like SolveBatch, but reads the problems from a channel and sends their results, in the same order, on the returned one, which is closed once problems is.
+// At most twice as many problems as there are workers are being solved or waiting for their turn to be sent at any time, so the results must be read for the solving to go on.
+func SolveStream(problems <-chan Problem, opts ...Option) <-chan Result {
+	workers := newConfig(opts).poolSize()
+	type job struct {
+		index   int
+		problem Problem
+	}
+	var (
+		jobs     = make(chan job)
+		solved   = make(chan Result)
+		results  = make(chan Result)
+		inFlight = make(chan struct{}, 2*workers)
+		wg       sync.WaitGroup
+	)
+
+	// Dispatch the problems
+	go func() {
+		i := 0
+		for p := range problems {
+			inFlight <- struct{}{}
+			jobs <- job{i, p}
+			i++
+		}
+		close(jobs)
+	}()
+
+	// Solve them
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				solved <- solveProblem(j.index, j.problem, opts)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(solved)
+	}()
+
+	// Send the results back in order
+	go func() {
+		pending := make(map[int]Result)
+		next := 0
+		for r := range solved {
+			pending[r.Index] = r
+			for {
+				r, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				results <- r
+				<-inFlight
+				next++
+			}
+		}
+		close(results)
+	}()
+
+	return results
+}
@@ -0,0 +1,89 @@
+package msa
+
+import (
+	"github.com/gyuho/goraph"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// batchProblems returns a mix of graph and matrix problems, every tenth one being invalid
+func batchProblems(r *rand.Rand, n int) []Problem {
+	problems := make([]Problem, n)
+	for i := range problems {
+		switch {
+		case i%10 == 9:
+			problems[i] = Problem{Graph: Goraph(randomGraph(r, 5, 10)), Root: goraph.StringID("unknown")}
+		case i%2 == 0:
+			problems[i] = Problem{Graph: Goraph(randomGraph(r, 20, 60)), Root: goraph.StringID("0")}
+		default:
+			problems[i] = Problem{Matrix: randomMatrix(r, 15, 0.5), MatrixRoot: r.Intn(15)}
+		}
+	}
+	return problems
+}
+
+// checkResults checks that results are those of solving problems one after the other
+func checkResults(t *testing.T, problems []Problem, results []Result) {
+	if len(results) != len(problems) {
+		t.Fatalf("Expected %d results, got %d", len(problems), len(results))
+	}
+	for i, r := range results {
+		expected := solveProblem(i, problems[i], []Option{WithAlgorithm(Tarjan)})
+		if r.Index != i {
+			t.Errorf("Result %d has index %d", i, r.Index)
+		}
+		if (r.Err == nil) != (expected.Err == nil) {
+			t.Errorf("Problem %d: expected error %v, got %v", i, expected.Err, r.Err)
+			continue
+		}
+		if expected.Arborescence != nil && r.Arborescence.Weight != expected.Arborescence.Weight {
+			t.Errorf("Problem %d: expected weight %v, got %v", i, expected.Arborescence.Weight, r.Arborescence.Weight)
+		}
+		if !reflect.DeepEqual(r.Heads, expected.Heads) {
+			t.Errorf("Problem %d: expected heads %v, got %v", i, expected.Heads, r.Heads)
+		}
+	}
+}
+
+func TestSolveBatch(t *testing.T) {
+	problems := batchProblems(rand.New(rand.NewSource(3)), 200)
+	for _, workers := range []int{0, 1, 7} {
+		checkResults(t, problems, SolveBatch(problems, WithWorkers(workers), WithAlgorithm(Tarjan)))
+	}
+	if results := SolveBatch(nil); len(results) != 0 {
+		t.Errorf("Expected no results, got %v", results)
+	}
+	if results := SolveBatch([]Problem{{}}); results[0].Err == nil {
+		t.Error("Expected an error for an empty problem")
+	}
+}
+
+func TestSolveStream(t *testing.T) {
+	problems := batchProblems(rand.New(rand.NewSource(4)), 200)
+	in := make(chan Problem)
+	go func() {
+		for _, p := range problems {
+			in <- p
+		}
+		close(in)
+	}()
+
+	var results []Result
+	for r := range SolveStream(in, WithWorkers(4), WithAlgorithm(Tarjan)) {
+		results = append(results, r)
+	}
+	checkResults(t, problems, results)
+}
+
+func BenchmarkSolveBatch_Matrix50(b *testing.B) {
+	r := rand.New(rand.NewSource(42))
+	problems := make([]Problem, 1000)
+	for i := range problems {
+		problems[i] = Problem{Matrix: randomMatrix(r, 50, 0), MatrixRoot: 0}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		SolveBatch(problems, WithObjective(Maximize))
+	}
+}
@@ -0,0 +1,85 @@
+package msa
+
+import (
+	"github.com/gyuho/goraph"
+	"math/rand"
+	"testing"
+)
+
+// benchmarkTestdata solves every graph of testdata/graph.json for every root
+func benchmarkTestdata(b *testing.B, opts ...Option) {
+	var graphs []goraph.Graph
+	for _, graphID := range testGraphIDs() {
+		graphs = append(graphs, loadGraph(b, graphID))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, g := range graphs {
+			for root := range g.GetNodes() {
+				Solve(g, root, opts...)
+			}
+		}
+	}
+}
+
+// benchmarkRandom solves a random graph of n nodes and 10n edges
+func benchmarkRandom(b *testing.B, n int, opts ...Option) {
+	g := randomGraph(rand.New(rand.NewSource(42)), n, 10*n)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Solve(g, goraph.StringID("0"), opts...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSolve_TestdataNaive(b *testing.B)  { benchmarkTestdata(b, WithAlgorithm(Naive)) }
+func BenchmarkSolve_TestdataTarjan(b *testing.B) { benchmarkTestdata(b, WithAlgorithm(Tarjan)) }
+
+func BenchmarkSolve_Random100Naive(b *testing.B)    { benchmarkRandom(b, 100, WithAlgorithm(Naive)) }
+func BenchmarkSolve_Random100Tarjan(b *testing.B)   { benchmarkRandom(b, 100, WithAlgorithm(Tarjan)) }
+func BenchmarkSolve_Random1000Naive(b *testing.B)   { benchmarkRandom(b, 1000, WithAlgorithm(Naive)) }
+func BenchmarkSolve_Random1000Tarjan(b *testing.B)  { benchmarkRandom(b, 1000, WithAlgorithm(Tarjan)) }
+func BenchmarkSolve_Random10000Tarjan(b *testing.B) { benchmarkRandom(b, 10000, WithAlgorithm(Tarjan)) }
+
+func BenchmarkMSA_Random100(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		// MSA modifies the graph, so build it anew every time
+		b.StopTimer()
+		g := randomGraph(rand.New(rand.NewSource(42)), 100, 1000)
+		b.StartTimer()
+		if _, err := MSA(g, goraph.StringID("0")); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchmarkDigraph runs an algorithm alone on an already converted random graph of n nodes and 10n edges
+func benchmarkDigraph(b *testing.B, n int, alg Algorithm) {
+	d, err := newDigraph(Goraph(randomGraph(rand.New(rand.NewSource(42)), n, 10*n)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	r := d.index["0"]
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if alg == Naive {
+			d.naive(r)
+		} else {
+			d.tarjan(r, nil)
+		}
+	}
+}
+
+func BenchmarkDigraph_Random1000Naive(b *testing.B)   { benchmarkDigraph(b, 1000, Naive) }
+func BenchmarkDigraph_Random10000Tarjan(b *testing.B) { benchmarkDigraph(b, 10000, Tarjan) }
+
+func BenchmarkSolveGraph_Random10000Adjacency(b *testing.B) {
+	a := adjacencyOf(b, randomGraph(rand.New(rand.NewSource(42)), 10000, 100000))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := SolveGraph(a, goraph.StringID("0"), WithAlgorithm(Tarjan)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
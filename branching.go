@@ -0,0 +1,336 @@
+package msa
+
+import (
+	"fmt"
+	"github.com/gyuho/goraph"
+	"math"
+)
+
+// Branching is a spanning forest of arborescences, as returned by MSB
+type Branching struct {
+	// Roots lists the IDs of the roots of the arborescences, sorted
+	Roots []goraph.ID
+
+	// Parent maps every non-root node ID to the edge coming into it
+	Parent map[goraph.ID]goraph.Edge
+
+	// Weight is the total weight of the edges of the branching, root costs not included
+	Weight float64
+
+	// Edges lists the edges of the branching
+	Edges []goraph.Edge
+}
+
+// MSB calculates the Minimum Spanning Branching of g, that is the spanning forest of arborescences of minimum weight, without modifying it.
+// Every root costs the amount set with WithRootCost, and WithMaxRoots limits their number.
+// With WithObjective(Maximize), it calculates the maximum one, root costs being subtracted.
+//
+// It works by adding an artificial root linked to every node by an edge weighing the root cost, and solving from it.
+// When the number of roots is limited and exceeded, a penalty is added to the root cost, which is searched by bisection until the limit is met.
+// If the limit falls between two penalties at which several branchings tie, the branching with more roots is completed by augmenting paths until it meets the limit.
+func MSB(g goraph.Graph, opts ...Option) (*Branching, error) {
+	b, err := msb(Goraph(g), opts)
+	if err != nil {
+		return nil, fmt.Errorf("MSB: %v", err)
+	}
+	return b, nil
+}
+
+// MSBGraph is like MSB, but works on any Graph
+func MSBGraph(g Graph, opts ...Option) (*Branching, error) {
+	b, err := msb(g, opts)
+	if err != nil {
+		return nil, fmt.Errorf("MSBGraph: %v", err)
+	}
+	return b, nil
+}
+
+// msb implements MSB and MSBGraph
+func msb(g Graph, opts []Option) (*Branching, error) {
+	c := newConfig(opts)
+	if math.IsNaN(c.rootCost) || math.IsInf(c.rootCost, 0) {
+		return nil, fmt.Errorf("root cost %v isn't a finite number", c.rootCost)
+	}
+	if c.maxRoots < 0 {
+		return nil, fmt.Errorf("invalid maximum number of roots %d", c.maxRoots)
+	}
+	if len(g.Nodes()) == 0 {
+		return &Branching{Parent: map[goraph.ID]goraph.Edge{}}, nil
+	}
+
+	sg, err := newSuperRooted(g)
+	if err != nil {
+		return nil, err
+	}
+
+	// solve solves with the given penalty added to the root cost
+	solve := func(penalty float64) (*Branching, error) {
+		err := sg.setWeight(c.sign() * (c.rootCost + penalty))
+		if err != nil {
+			return nil, err
+		}
+		roots, arb, err := sg.solve(opts)
+		if err != nil {
+			return nil, err
+		}
+		return &Branching{
+			Roots:  roots,
+			Parent: arb.Parent,
+			Weight: arb.Weight,
+			Edges:  arb.Edges,
+		}, nil
+	}
+
+	b, err := solve(0)
+	if err != nil {
+		return nil, err
+	}
+	if c.maxRoots == 0 || len(b.Roots) <= c.maxRoots {
+		return b, nil
+	}
+
+	// With a heavy enough penalty, the number of roots is the lowest possible
+	lo, hi := 0.0, sg.heavy()+math.Abs(c.rootCost)
+	lb := b
+	b, err = solve(hi)
+	if err != nil {
+		return nil, err
+	}
+	if len(b.Roots) > c.maxRoots {
+		return nil, fmt.Errorf("at least %d roots are needed, more than the maximum of %d", len(b.Roots), c.maxRoots)
+	}
+
+	// Bisect the penalty until reaching the limit, keeping the solutions of both bounds
+	for len(b.Roots) != c.maxRoots {
+		mid := lo + (hi-lo)/2
+		if mid <= lo || mid >= hi {
+			break
+		}
+		mb, err := solve(mid)
+		if err != nil {
+			return nil, err
+		}
+		if len(mb.Roots) > c.maxRoots {
+			lo, lb = mid, mb
+		} else {
+			hi, b = mid, mb
+		}
+	}
+	if len(b.Roots) == c.maxRoots {
+		return b, nil
+	}
+
+	// No penalty gives exactly the limit: every branching optimal for a penalty is the best of those with as many roots,
+	// but the lower bound's one has too many of them, and the upper bound's too few.
+	return augmentBranching(g, lb, c.maxRoots, c)
+}
+
+// augmentBranching adds edges to b, a branching of g of minimum weight among those with as many roots, until it has maxRoots roots, keeping it so
+// Branchings are the common independent sets of the graphic matroid and of the partition matroid allowing one edge into every node,
+// so this is the weighted matroid intersection algorithm: the shortest augmenting path of the exchange graph gives a minimum branching with one more edge.
+// See A. Schrijver, "Combinatorial Optimization", section 41.3
+func augmentBranching(g Graph, b *Branching, maxRoots int, c *config) (*Branching, error) {
+	d, err := newDigraph(g)
+	if err != nil {
+		return nil, fmt.Errorf("augmentBranching: error while converting graph: %v", err)
+	}
+	d.constrain(c)
+	d.scale(c.sign())
+	d.best()
+
+	arcs := make(map[[2]int][]int, len(d.arcs))
+	for a, arc := range d.arcs {
+		arcs[[2]int{arc.from, arc.to}] = append(arcs[[2]int{arc.from, arc.to}], a)
+	}
+	in := make([]int, len(d.nodes))
+	for v := range in {
+		in[v] = -1
+	}
+	for _, e := range b.Edges {
+		u, okSource := d.index[e.Source().ID().String()]
+		v, okTarget := d.index[e.Target().ID().String()]
+		a := -1
+		if okSource && okTarget {
+			a = d.match(arcs[[2]int{u, v}], e)
+		}
+		if a < 0 {
+			return nil, fmt.Errorf("augmentBranching: edge %s isn't in the graph", e.String())
+		}
+		in[v] = a
+	}
+	required := make([]bool, len(d.arcs))
+	for a, arc := range d.arcs {
+		_, required[a] = c.required[edgeKey(d.nodes[arc.from].ID(), d.nodes[arc.to].ID())]
+	}
+
+	for roots := len(b.Roots); roots > maxRoots; roots-- {
+		if !d.augment(in, required) {
+			return nil, fmt.Errorf("augmentBranching: no branching has %d roots", maxRoots)
+		}
+	}
+	d.scale(c.sign())
+
+	res := &Branching{Parent: make(map[goraph.ID]goraph.Edge, len(d.nodes))}
+	for v, a := range in {
+		if a < 0 {
+			res.Roots = append(res.Roots, d.nodes[v].ID())
+			continue
+		}
+		e := d.edge(a)
+		res.Parent[e.Target().ID()] = e
+		res.Edges = append(res.Edges, e)
+		res.Weight += e.Weight()
+	}
+	return res, nil
+}
+
+// augment adds an edge to the branching whose incoming arc index of every node is in, -1 for roots, through the shortest augmenting path of the exchange graph
+// Required arcs are never removed. It returns false if no path exists, that is if the branching already has as few roots as possible.
+func (d *digraph) augment(in []int, required []bool) bool {
+	// The arborescences of the branching, as an undirected forest
+	var (
+		tree  = make([]int, len(d.nodes)) // the root of the arborescence of every node
+		depth = make([]int, len(d.nodes))
+	)
+	var locate func(v int)
+	locate = func(v int) {
+		switch {
+		case tree[v] >= 0:
+		case in[v] < 0:
+			tree[v] = v
+		default:
+			u := d.arcs[in[v]].from
+			locate(u)
+			tree[v], depth[v] = tree[u], depth[u]+1
+		}
+	}
+	for v := range tree {
+		tree[v] = -1
+	}
+	for v := range tree {
+		locate(v)
+	}
+	inBranching := func(a int) bool { return in[d.arcs[a].to] == a }
+
+	// Arcs of the exchange graph, x being in the branching and y not:
+	// - x -> y if removing x and adding y leaves a forest, that is if x is on the path of the forest linking the endpoints of y
+	// - y -> x if x is the arc of the branching going into the target of y
+	// Arcs from every x to y when y joins two arborescences, and from y to every x when the target of y is a root, are implicit.
+	var (
+		sources = make([]bool, len(d.arcs)) // adding y leaves a forest
+		sinks   = make([]bool, len(d.arcs)) // adding y leaves one arc into every node
+		cycle   = make([][]int, len(d.arcs))
+	)
+	for y, arc := range d.arcs {
+		if inBranching(y) {
+			continue
+		}
+		u, v := arc.from, arc.to
+		sources[y] = tree[u] != tree[v]
+		sinks[y] = in[v] < 0
+		if sources[y] {
+			continue
+		}
+		for u != v {
+			if depth[u] < depth[v] {
+				u, v = v, u
+			}
+			if x := in[u]; !required[x] {
+				cycle[x] = append(cycle[x], y)
+			}
+			u = d.arcs[in[u]].from
+		}
+	}
+
+	// Bellman-Ford on the vertex lengths, w(y) for the arcs added and -w(x) for those removed, breaking ties by the number of arcs
+	var (
+		dist = make([]float64, len(d.arcs))
+		hops = make([]int, len(d.arcs))
+		prev = make([]int, len(d.arcs))
+	)
+	for a := range dist {
+		dist[a], prev[a] = math.Inf(1), -1
+		if sources[a] {
+			dist[a] = d.arcs[a].weight
+		}
+	}
+	shorter := func(da float64, ha int, db float64, hb int) bool {
+		return da < db || (da == db && ha < hb)
+	}
+	relax := func(from int, to int, length float64) bool {
+		if math.IsInf(dist[from], 1) || !shorter(dist[from]+length, hops[from]+1, dist[to], hops[to]) {
+			return false
+		}
+		dist[to], hops[to], prev[to] = dist[from]+length, hops[from]+1, from
+		return true
+	}
+	for round := 0; round <= len(d.arcs); round++ {
+		changed := false
+		bestX, bestSink := -1, -1
+		for a := range d.arcs {
+			switch {
+			case inBranching(a):
+				if required[a] {
+					continue
+				}
+				for _, y := range cycle[a] {
+					changed = relax(a, y, d.arcs[y].weight) || changed
+				}
+				if bestX < 0 || shorter(dist[a], hops[a], dist[bestX], hops[bestX]) {
+					bestX = a
+				}
+			default:
+				if x := in[d.arcs[a].to]; x >= 0 && !required[x] {
+					changed = relax(a, x, -d.arcs[x].weight) || changed
+				}
+				if sinks[a] && (bestSink < 0 || shorter(dist[a], hops[a], dist[bestSink], hops[bestSink])) {
+					bestSink = a
+				}
+			}
+		}
+		for a := range d.arcs {
+			switch {
+			case inBranching(a):
+				if bestSink >= 0 && !required[a] {
+					changed = relax(bestSink, a, -d.arcs[a].weight) || changed
+				}
+			case sources[a]:
+				if bestX >= 0 {
+					changed = relax(bestX, a, d.arcs[a].weight) || changed
+				}
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	// Exchange the arcs along the shortest path to a sink
+	end := -1
+	for a := range d.arcs {
+		if sinks[a] && !math.IsInf(dist[a], 1) && (end < 0 || shorter(dist[a], hops[a], dist[end], hops[end])) {
+			end = a
+		}
+	}
+	if end < 0 {
+		return false
+	}
+	var added, removed []int
+	for a := end; a >= 0; a = prev[a] {
+		if len(added)+len(removed) > len(d.arcs) {
+			return false
+		}
+		if inBranching(a) {
+			removed = append(removed, a)
+		} else {
+			added = append(added, a)
+		}
+	}
+	for _, a := range removed {
+		in[d.arcs[a].to] = -1
+	}
+	for _, a := range added {
+		in[d.arcs[a].to] = a
+	}
+	return true
+}
@@ -0,0 +1,184 @@
+package msa
+
+import (
+	"fmt"
+	"github.com/gyuho/goraph"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestMSB(t *testing.T) {
+	// Two strongly connected components, the second one being reachable from the first
+	weights := map[string]float64{"A B": 1, "B C": 2, "C A": 3, "D E": 1, "E D": 1, "A D": 10}
+
+	tests := []struct {
+		opts   []Option
+		weight float64
+		roots  int
+	}{
+		// Every weight is positive, so every node is better off as a root
+		{nil, 0, 5},
+		{[]Option{WithRootCost(5)}, 4, 2},
+		{[]Option{WithRootCost(100)}, 14, 1},
+		{[]Option{WithMaxRoots(2)}, 4, 2},
+		{[]Option{WithMaxRoots(1)}, 14, 1},
+		{[]Option{WithRootCost(5), WithMaxRoots(1)}, 14, 1},
+		{[]Option{WithMaxRoots(3)}, 2, 3},
+		// When maximizing, every edge is worth taking unless it creates a cycle
+		{[]Option{WithObjective(Maximize)}, 16, 1},
+		// A negative root cost rewards roots, so edges lighter than it aren't worth it
+		{[]Option{WithObjective(Maximize), WithRootCost(-2.5)}, 13, 3},
+	}
+
+	for i, test := range tests {
+		for _, alg := range []Algorithm{Naive, Tarjan} {
+			g := newTestGraph(t, weights)
+			b, err := MSB(g, append(test.opts, WithAlgorithm(alg))...)
+			if err != nil {
+				t.Errorf("Test %d (%v): unexpected error: %v", i, alg, err)
+				continue
+			}
+			if b.Weight != test.weight || len(b.Roots) != test.roots {
+				t.Errorf("Test %d (%v): expected weight %v with %d roots, got %v with roots %v", i, alg, test.weight, test.roots, b.Weight, b.Roots)
+			}
+			if len(b.Parent)+len(b.Roots) != g.GetNodeCount() {
+				t.Errorf("Test %d (%v): %d parents and %d roots don't span the %d nodes", i, alg, len(b.Parent), len(b.Roots), g.GetNodeCount())
+			}
+			for _, root := range b.Roots {
+				if _, ok := b.Parent[root]; ok {
+					t.Errorf("Test %d (%v): root %s has a parent", i, alg, root)
+				}
+			}
+		}
+	}
+}
+
+func TestMSB_NegativeWeights(t *testing.T) {
+	g := newTestGraph(t, map[string]float64{"A B": -1, "B A": -2, "C B": 4})
+	b, err := MSB(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Weight != -2 || len(b.Roots) != 2 || b.Roots[0].String() != "B" || b.Roots[1].String() != "C" {
+		t.Errorf("Expected weight -2 with roots B and C, got %v with roots %v", b.Weight, b.Roots)
+	}
+}
+
+func TestMSB_TooFewRoots(t *testing.T) {
+	// graph_05 needs several roots
+	if _, err := MSB(loadGraph(t, "graph_05"), WithMaxRoots(1)); err == nil {
+		t.Error("Expected an error when no branching can have a single root")
+	}
+}
+
+// Test that limiting the number of roots between two breakpoints of the penalty still gives the best branching
+func TestMSB_MaxRootsBetweenBreakpoints(t *testing.T) {
+	g := newTestGraph(t, map[string]float64{"a b": 5, "b c": 5})
+	for _, alg := range []Algorithm{Naive, Tarjan} {
+		b, err := MSB(g, WithMaxRoots(2), WithAlgorithm(alg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.Weight != 5 || len(b.Roots) != 2 {
+			t.Errorf("%v: expected weight 5 with 2 roots, got %v with roots %v", alg, b.Weight, b.Roots)
+		}
+	}
+}
+
+// bruteForceBranchings returns, for every number of roots, the minimum weight of the branchings of g with that many roots, +Inf if there is none
+func bruteForceBranchings(t *testing.T, g goraph.Graph, sign float64) []float64 {
+	edges, err := GetEdges(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nodes []string
+	for id := range g.GetNodes() {
+		nodes = append(nodes, id.String())
+	}
+	incoming := make(map[string][]goraph.Edge)
+	for _, e := range edges {
+		if e.Source().ID().String() != e.Target().ID().String() {
+			incoming[e.Target().ID().String()] = append(incoming[e.Target().ID().String()], e)
+		}
+	}
+
+	best := make([]float64, len(nodes)+1)
+	for i := range best {
+		best[i] = math.Inf(1)
+	}
+	parent := make(map[string]string, len(nodes))
+	var enumerate func(i int, roots int, weight float64)
+	enumerate = func(i int, roots int, weight float64) {
+		if i == len(nodes) {
+			for _, v := range nodes {
+				// Walking up from any node must reach a root
+				u := v
+				for steps := 0; parent[u] != ""; steps++ {
+					if steps > len(nodes) {
+						return
+					}
+					u = parent[u]
+				}
+			}
+			best[roots] = math.Min(best[roots], weight)
+			return
+		}
+		v := nodes[i]
+		parent[v] = ""
+		enumerate(i+1, roots+1, weight)
+		for _, e := range incoming[v] {
+			parent[v] = e.Source().ID().String()
+			enumerate(i+1, roots, weight+sign*e.Weight())
+		}
+		parent[v] = ""
+	}
+	enumerate(0, 0, 0)
+	return best
+}
+
+// Test MSB against a brute-force enumeration of small branchings, ties being frequent
+func TestMSB_BruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(8))
+	for i := 0; i < 300; i++ {
+		n := 2 + r.Intn(5)
+		weights := make(map[string]float64)
+		for j := r.Intn(3 * n); j >= 0; j-- {
+			weights[fmt.Sprintf("%d %d", r.Intn(n), r.Intn(n))] = float64(r.Intn(5) - 1)
+		}
+		g := newTestGraph(t, weights)
+		for _, objective := range []Objective{Minimize, Maximize} {
+			sign := 1.0
+			if objective == Maximize {
+				sign = -1
+			}
+			best := bruteForceBranchings(t, g, sign)
+			cost := float64(r.Intn(4))
+			for maxRoots := 1; maxRoots <= g.GetNodeCount(); maxRoots++ {
+				expected, fewest := math.Inf(1), -1
+				for roots := 1; roots <= maxRoots; roots++ {
+					expected = math.Min(expected, best[roots]+cost*float64(roots))
+					if fewest < 0 && !math.IsInf(best[roots], 1) {
+						fewest = roots
+					}
+				}
+				for _, alg := range []Algorithm{Naive, Tarjan} {
+					b, err := MSB(g, WithObjective(objective), WithRootCost(cost), WithMaxRoots(maxRoots), WithAlgorithm(alg))
+					if fewest < 0 {
+						if err == nil {
+							t.Errorf("Graph %v (%v, %v): expected an error with at most %d roots", weights, objective, alg, maxRoots)
+						}
+						continue
+					}
+					if err != nil {
+						t.Fatalf("Graph %v (%v, %v): unexpected error with at most %d roots: %v", weights, objective, alg, maxRoots, err)
+					}
+					got := sign*b.Weight + cost*float64(len(b.Roots))
+					if got != expected || len(b.Roots) > maxRoots || len(b.Parent)+len(b.Roots) != g.GetNodeCount() {
+						t.Errorf("Graph %v (%v, %v, root cost %v, at most %d roots): expected %v, got weight %v with roots %v", weights, objective, alg, cost, maxRoots, expected, b.Weight, b.Roots)
+					}
+				}
+			}
+		}
+	}
+}
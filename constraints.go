@@ -0,0 +1,129 @@
+package msa
+
+import (
+	"fmt"
+	"github.com/gyuho/goraph"
+	"sort"
+	"strings"
+)
+
+// ConstraintError is returned when the edges required with WithRequired and forbidden with WithForbidden can't all be satisfied
+type ConstraintError struct {
+	// Edges are the offending edges
+	Edges []goraph.Edge
+
+	// Reason explains why they can't be satisfied
+	Reason string
+}
+
+// Error lists the offending edges along with the reason
+func (e *ConstraintError) Error() string {
+	edges := make([]string, len(e.Edges))
+	for i, edge := range e.Edges {
+		edges[i] = edge.Source().ID().String() + " -> " + edge.Target().ID().String()
+	}
+	return fmt.Sprintf("msa: unsatisfiable constraint on %s: %s", strings.Join(edges, ", "), e.Reason)
+}
+
+// checkConstraints returns a *ConstraintError if the required and forbidden edges contradict each other or g
+func (c *config) checkConstraints(g Graph, root goraph.ID) error {
+	required := make([]goraph.Edge, 0, len(c.required))
+	for _, e := range c.required {
+		required = append(required, e)
+	}
+	sort.Sort(edgesByID(required))
+
+	parents := make(map[string]goraph.Edge, len(required))
+	for _, e := range required {
+		source, target := e.Source().ID(), e.Target().ID()
+		if _, ok := c.forbidden[edgeKey(source, target)]; ok {
+			return &ConstraintError{Edges: []goraph.Edge{e}, Reason: "the edge is both required and forbidden"}
+		}
+		if !hasEdge(g, source, target) {
+			return &ConstraintError{Edges: []goraph.Edge{e}, Reason: "the edge isn't in the graph"}
+		}
+		if target.String() == root.String() {
+			return &ConstraintError{Edges: []goraph.Edge{e}, Reason: "the edge goes to the root"}
+		}
+		if other, ok := parents[target.String()]; ok {
+			return &ConstraintError{Edges: []goraph.Edge{other, e}, Reason: "the edges go to the same node"}
+		}
+		parents[target.String()] = e
+	}
+
+	// Every node has at most one required parent, so following them from any node either ends or loops
+	done := make(map[string]struct{}, len(parents))
+	for _, e := range required {
+		var (
+			path    []goraph.Edge
+			onPath  = make(map[string]int)
+			current = e.Target().ID().String()
+		)
+		for {
+			if _, ok := done[current]; ok {
+				break
+			}
+			if i, ok := onPath[current]; ok {
+				return &ConstraintError{Edges: path[i:], Reason: "the edges make a cycle"}
+			}
+			parent, ok := parents[current]
+			if !ok {
+				break
+			}
+			onPath[current] = len(path)
+			path = append(path, parent)
+			current = parent.Source().ID().String()
+		}
+		for node := range onPath {
+			done[node] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// requiredSources maps the target of every required edge to its source
+func (c *config) requiredSources() map[string]string {
+	sources := make(map[string]string, len(c.required))
+	for e := range c.required {
+		sources[e.target] = e.source
+	}
+	return sources
+}
+
+// allowed returns whether the constraints allow the edge going from source to target in the arborescence
+func (c *config) allowed(requiredSources map[string]string, source string, target string) bool {
+	if _, ok := c.forbidden[edgeID{source, target}]; ok {
+		return false
+	}
+	if requiredSource, ok := requiredSources[target]; ok && requiredSource != source {
+		return false
+	}
+	return true
+}
+
+// constrain removes from d the forbidden arcs, as well as the arcs competing with required ones
+func (d *digraph) constrain(c *config) {
+	if len(c.required) == 0 && len(c.forbidden) == 0 {
+		return
+	}
+
+	requiredSources := c.requiredSources()
+	arcs := d.arcs[:0]
+	for _, a := range d.arcs {
+		if c.allowed(requiredSources, d.nodes[a.from].ID().String(), d.nodes[a.to].ID().String()) {
+			arcs = append(arcs, a)
+		}
+	}
+	d.arcs = arcs
+	d.compress()
+}
+
+// edgesByID sorts edges by source ID, then target ID
+type edgesByID []goraph.Edge
+
+func (s edgesByID) Len() int { return len(s) }
+func (s edgesByID) Less(i, j int) bool {
+	a, b := edgeKey(s[i].Source().ID(), s[i].Target().ID()), edgeKey(s[j].Source().ID(), s[j].Target().ID())
+	return a.source < b.source || (a.source == b.source && a.target < b.target)
+}
+func (s edgesByID) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
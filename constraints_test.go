@@ -0,0 +1,95 @@
+package msa
+
+import (
+	"github.com/gyuho/goraph"
+	"testing"
+)
+
+// testEdge returns an edge going from source to target, for use as a constraint
+func testEdge(source string, target string) goraph.Edge {
+	return goraph.NewEdge(goraph.NewNode(source), goraph.NewNode(target), 0)
+}
+
+func TestSolve_Constraints(t *testing.T) {
+	g := loadGraph(t, "graph_17")
+	tests := []struct {
+		opts   []Option
+		weight float64
+	}{
+		{nil, 15},
+		{[]Option{WithRequired(testEdge("C", "B"))}, 19},
+		{[]Option{WithForbidden(testEdge("D", "C"))}, 17},
+		{[]Option{WithRequired(testEdge("A", "B")), WithForbidden(testEdge("D", "C"))}, 17},
+	}
+
+	for i, test := range tests {
+		for _, alg := range []Algorithm{Naive, Tarjan} {
+			arb, err := Solve(g, goraph.StringID("D"), append(test.opts, WithAlgorithm(alg))...)
+			if err != nil {
+				t.Errorf("Test %d (%v): unexpected error: %v", i, alg, err)
+				continue
+			}
+			if arb.Weight != test.weight {
+				t.Errorf("Test %d (%v): expected weight %v, got %v", i, alg, test.weight, arb.Weight)
+			}
+		}
+	}
+
+	// A can only be reached through D -> A
+	_, err := Solve(g, goraph.StringID("D"), WithForbidden(testEdge("D", "A")))
+	if _, ok := err.(*InfeasibleError); !ok {
+		t.Errorf("Expected an *InfeasibleError, got %v", err)
+	}
+}
+
+func TestSolve_ConstraintErrors(t *testing.T) {
+	g := loadGraph(t, "graph_17")
+	tests := []struct {
+		opts  []Option
+		edges int
+	}{
+		// Two required edges into the same node
+		{[]Option{WithRequired(testEdge("A", "B"), testEdge("C", "B"))}, 2},
+		// A required cycle
+		{[]Option{WithRequired(testEdge("B", "C"), testEdge("C", "B"))}, 2},
+		// A required edge into the root
+		{[]Option{WithRequired(testEdge("B", "D"))}, 1},
+		// A required edge that doesn't exist
+		{[]Option{WithRequired(testEdge("A", "C"))}, 1},
+		// An edge both required and forbidden
+		{[]Option{WithRequired(testEdge("A", "B")), WithForbidden(testEdge("A", "B"))}, 1},
+	}
+
+	for i, test := range tests {
+		_, err := Solve(g, goraph.StringID("D"), test.opts...)
+		cerr, ok := err.(*ConstraintError)
+		if !ok {
+			t.Errorf("Test %d: expected a *ConstraintError, got %v", i, err)
+			continue
+		}
+		if len(cerr.Edges) != test.edges {
+			t.Errorf("Test %d: expected %d offending edges, got %v", i, test.edges, cerr)
+		}
+	}
+}
+
+func TestKBest_Constraints(t *testing.T) {
+	// With D -> C forbidden, only one arborescence is left rooted at D
+	g := loadGraph(t, "graph_17")
+	ranked, err := KBest(g, goraph.StringID("D"), 3, WithForbidden(testEdge("D", "C")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranked) != 1 || ranked[0].Weight != 17 {
+		t.Errorf("Expected a single arborescence of weight 17, got %d", len(ranked))
+	}
+
+	// Requiring C -> B leaves a single one too
+	ranked, err = KBest(g, goraph.StringID("D"), 3, WithRequired(testEdge("C", "B")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranked) != 1 || ranked[0].Weight != 19 {
+		t.Errorf("Expected a single arborescence of weight 19, got %d", len(ranked))
+	}
+}
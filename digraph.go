@@ -0,0 +1,184 @@
+package msa
+
+import (
+	"fmt"
+	"github.com/gyuho/goraph"
+	"math"
+	"sort"
+)
+
+// arc is an edge of a digraph, its endpoints being node indexes
+type arc struct {
+	from, to int
+	weight   float64
+	edge     int // the index of the edge of the input it comes from
+}
+
+// digraph is an integer-indexed copy of a Graph, on which the solvers work
+// Arcs are stored in compressed sparse row form: those leaving node u are arcs[out[u]:out[u+1]]
+// There may be several arcs going from a node to another, such as the alternatives of a LabeledEdge
+type digraph struct {
+	nodes []goraph.Node
+	index map[string]int
+	arcs  []arc
+	out   []int
+	edges []goraph.Edge // the edges of the input the arcs come from
+}
+
+// newDigraph converts g into a digraph
+// Nodes are sorted by ID, and arcs by source, target then input order, so that the result doesn't depend on map iteration order
+// Self-loops are dropped as they can't be part of an arborescence, and weights that aren't finite numbers are rejected
+func newDigraph(g Graph) (*digraph, error) {
+	ids := g.Nodes()
+	d := &digraph{
+		nodes: make([]goraph.Node, 0, len(ids)),
+		index: make(map[string]int, len(ids)),
+	}
+	for _, id := range ids {
+		d.nodes = append(d.nodes, goraph.NewNode(id.String()))
+	}
+	sort.Sort(nodesByID(d.nodes))
+	for i, node := range d.nodes {
+		if _, ok := d.index[node.ID().String()]; ok {
+			return nil, fmt.Errorf("newDigraph: node %s appears several times", node.ID().String())
+		}
+		d.index[node.ID().String()] = i
+	}
+
+	for j, node := range d.nodes {
+		edges, err := g.Incoming(node.ID())
+		if err != nil {
+			return nil, fmt.Errorf("newDigraph: error while retrieving edges going to %s: %v", node.ID().String(), err)
+		}
+		for _, e := range edges {
+			sourceID := e.Source().ID().String()
+			i, ok := d.index[sourceID]
+			if !ok {
+				return nil, fmt.Errorf("newDigraph: edge going from %s to %s comes from a node that isn't in the graph", sourceID, node.ID().String())
+			}
+			weight := e.Weight()
+			if math.IsNaN(weight) || math.IsInf(weight, 0) {
+				return nil, fmt.Errorf("newDigraph: edge going from %s to %s has weight %v, which isn't a finite number", sourceID, node.ID().String(), weight)
+			}
+			if i == j {
+				continue
+			}
+			d.arcs = append(d.arcs, arc{from: i, to: j, weight: weight, edge: len(d.edges)})
+			d.edges = append(d.edges, e)
+		}
+	}
+	sort.Sort(arcsBySource(d.arcs))
+	d.compress()
+
+	return d, nil
+}
+
+// compress computes the row offsets of the arcs, which must be sorted by source
+func (d *digraph) compress() {
+	d.out = make([]int, len(d.nodes)+1)
+	for _, a := range d.arcs {
+		d.out[a.from+1]++
+	}
+	for u := 0; u < len(d.nodes); u++ {
+		d.out[u+1] += d.out[u]
+	}
+}
+
+// best only keeps the lightest of the arcs going from a node to another, the first one in input order on ties
+// Arcs must be sorted by arcsBySource
+func (d *digraph) best() {
+	arcs := d.arcs[:0]
+	for _, a := range d.arcs {
+		if n := len(arcs); n != 0 && arcs[n-1].from == a.from && arcs[n-1].to == a.to {
+			if a.weight < arcs[n-1].weight {
+				arcs[n-1] = a
+			}
+			continue
+		}
+		arcs = append(arcs, a)
+	}
+	d.arcs = arcs
+	d.compress()
+}
+
+// scale multiplies the weight of every arc by factor
+func (d *digraph) scale(factor float64) {
+	if factor == 1 {
+		return
+	}
+	for i := range d.arcs {
+		d.arcs[i].weight *= factor
+	}
+}
+
+// unreachable returns the IDs of the nodes that can't be reached from root, sorted
+func (d *digraph) unreachable(root int) []goraph.ID {
+	reached := make([]bool, len(d.nodes))
+	reached[root] = true
+	stack := []int{root}
+	for len(stack) != 0 {
+		u := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, a := range d.arcs[d.out[u]:d.out[u+1]] {
+			if v := a.to; !reached[v] {
+				reached[v] = true
+				stack = append(stack, v)
+			}
+		}
+	}
+
+	// Nodes are sorted by ID, so the result is too
+	var unreachable []goraph.ID
+	for v, ok := range reached {
+		if !ok {
+			unreachable = append(unreachable, d.nodes[v].ID())
+		}
+	}
+	return unreachable
+}
+
+// edge returns the edge of the input the arc at index a comes from, so that parallel edges are told apart
+func (d *digraph) edge(a int) goraph.Edge {
+	return d.edges[d.arcs[a].edge]
+}
+
+// arborescence builds an Arborescence out of the incoming arc index of every node, the root's being ignored
+func (d *digraph) arborescence(root int, in []int) *Arborescence {
+	arb := &Arborescence{
+		Root:   d.nodes[root].ID(),
+		Parent: make(map[goraph.ID]goraph.Edge, len(d.nodes)-1),
+		Edges:  make([]goraph.Edge, 0, len(d.nodes)-1),
+	}
+	for v, a := range in {
+		if v == root {
+			continue
+		}
+		e := d.edge(a)
+		arb.Parent[e.Target().ID()] = e
+		arb.Edges = append(arb.Edges, e)
+		arb.Weight += e.Weight()
+	}
+	return arb
+}
+
+// nodesByID sorts nodes by the string representation of their ID
+type nodesByID []goraph.Node
+
+func (s nodesByID) Len() int           { return len(s) }
+func (s nodesByID) Less(i, j int) bool { return s[i].ID().String() < s[j].ID().String() }
+func (s nodesByID) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// arcsBySource sorts arcs by the index of their source, then of their target, then of the edge they come from
+type arcsBySource []arc
+
+func (s arcsBySource) Len() int { return len(s) }
+func (s arcsBySource) Less(i, j int) bool {
+	if s[i].from != s[j].from {
+		return s[i].from < s[j].from
+	}
+	if s[i].to != s[j].to {
+		return s[i].to < s[j].to
+	}
+	return s[i].edge < s[j].edge
+}
+func (s arcsBySource) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
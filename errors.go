@@ -0,0 +1,29 @@
+package msa
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gyuho/goraph"
+	"strings"
+)
+
+// InfeasibleError is returned when a graph has no spanning arborescence rooted at the requested root, because some nodes can't be reached from it
+type InfeasibleError struct {
+	// Root is the requested root
+	Root goraph.ID
+
+	// Unreachable lists the IDs of the nodes that can't be reached from Root, sorted
+	Unreachable []goraph.ID
+}
+
+// Error lists the unreachable nodes
+func (e *InfeasibleError) Error() string {
+	ids := make([]string, len(e.Unreachable))
+	for i, id := range e.Unreachable {
+		ids[i] = id.String()
+	}
+	return fmt.Sprintf("msa: no spanning arborescence is rooted at %s, as %d nodes can't be reached from it: %s", e.Root.String(), len(ids), strings.Join(ids, ", "))
+}
+
+// ErrNoRoot is returned when looking for the best root of a graph in which no node can reach every other one
+var ErrNoRoot = errors.New("msa: no node can reach every other one")
@@ -0,0 +1,158 @@
+//go:build go1.18
+// +build go1.18
+
+package msa
+
+import (
+	"github.com/gyuho/goraph"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// Fuzzing requires Go 1.18, run it with:
+//	go test -fuzz FuzzSolve
+// Failing inputs are minimized and saved in testdata/fuzz/FuzzSolve, where they become part of the regular tests.
+
+// fuzzGraph decodes a graph from fuzzing data
+// The first byte gives the number of nodes, the second one the root, and then every three bytes an edge: its source, target and weight
+// Weights are signed bytes, so that ties, zero and negative weights are common, and so are self-loops and unreachable nodes
+func fuzzGraph(data []byte) (goraph.Graph, goraph.ID) {
+	if len(data) < 2 {
+		return nil, nil
+	}
+	n := int(data[0])%12 + 1
+	g := goraph.NewGraph()
+	for i := 0; i < n; i++ {
+		g.AddNode(goraph.NewNode(strconv.Itoa(i)))
+	}
+	for i := 2; i+3 <= len(data); i += 3 {
+		source := goraph.StringID(strconv.Itoa(int(data[i]) % n))
+		target := goraph.StringID(strconv.Itoa(int(data[i+1]) % n))
+		g.ReplaceEdge(source, target, float64(int8(data[i+2])))
+	}
+	return g, goraph.StringID(strconv.Itoa(int(data[1]) % n))
+}
+
+// encodeFuzzGraph encodes a graph of testdata/graph.json for fuzzGraph, clamping its weights
+func encodeFuzzGraph(t testing.TB, g goraph.Graph, root goraph.ID) []byte {
+	var ids []string
+	for id := range g.GetNodes() {
+		ids = append(ids, id.String())
+	}
+	sort.Strings(ids)
+	index := make(map[string]byte, len(ids))
+	for i, id := range ids {
+		index[id] = byte(i)
+	}
+
+	data := []byte{byte(len(ids) - 1), index[root.String()]}
+	edges, err := GetEdges(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Sort(edgesByID(edges))
+	for _, e := range edges {
+		weight := e.Weight()
+		if weight > 127 {
+			weight = 127
+		}
+		data = append(data, index[e.Source().ID().String()], index[e.Target().ID().String()], byte(int8(weight)))
+	}
+	return data
+}
+
+func FuzzSolve(f *testing.F) {
+	for _, graphID := range testGraphIDs() {
+		g := loadGraph(f, graphID)
+		for root := range g.GetNodes() {
+			f.Add(encodeFuzzGraph(f, g, root))
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		g, root := fuzzGraph(data)
+		if g == nil {
+			return
+		}
+		before := edgeStrings(t, g)
+
+		for _, obj := range []Objective{Minimize, Maximize} {
+			naive, naiveErr := Solve(g, root, WithObjective(obj), WithAlgorithm(Naive), WithDuals())
+			tarjan, tarjanErr := Solve(g, root, WithObjective(obj), WithAlgorithm(Tarjan), WithDuals())
+
+			// The input is left untouched
+			if after := edgeStrings(t, g); !reflect.DeepEqual(before, after) {
+				t.Fatalf("The graph was modified: %v became %v", before, after)
+			}
+
+			// Both algorithms agree on feasibility, an infeasible graph having an unreachable node
+			_, naiveInfeasible := naiveErr.(*InfeasibleError)
+			_, tarjanInfeasible := tarjanErr.(*InfeasibleError)
+			if (naiveErr != nil && !naiveInfeasible) || (tarjanErr != nil && !tarjanInfeasible) {
+				t.Fatalf("Unexpected errors: %v, %v", naiveErr, tarjanErr)
+			}
+			if naiveInfeasible != tarjanInfeasible {
+				t.Fatalf("Naive returned error %v, Tarjan %v", naiveErr, tarjanErr)
+			}
+			if naiveInfeasible {
+				return
+			}
+
+			for _, arb := range []*Arborescence{naive, tarjan} {
+				// The result is spanning, every node but the root has a single parent leading back to it
+				if len(arb.Parent) != g.GetNodeCount()-1 {
+					t.Fatalf("Expected %d parents, got %d", g.GetNodeCount()-1, len(arb.Parent))
+				}
+				for id := range g.GetNodes() {
+					v := id.String()
+					for steps := 0; v != root.String(); steps++ {
+						if steps == g.GetNodeCount() {
+							t.Fatalf("Node %s doesn't lead back to the root", id)
+						}
+						e, ok := arb.Parent[goraph.StringID(v)]
+						if !ok {
+							t.Fatalf("Node %s has no parent", v)
+						}
+						v = e.Source().ID().String()
+					}
+				}
+
+				// The dual variables certify it is optimal
+				if err := Verify(g, root, arb, WithObjective(obj)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if naive.Weight != tarjan.Weight {
+				t.Fatalf("Naive found weight %v, Tarjan %v", naive.Weight, tarjan.Weight)
+			}
+
+			// No sampled arborescence is better
+			src := rand.NewSource(int64(len(data)))
+			for i := 0; i < 10; i++ {
+				sample, err := SampleUniform(g, root, src)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if sign := newConfig([]Option{WithObjective(obj)}).sign(); sign*sample.Weight < sign*naive.Weight {
+					t.Fatalf("Sampled arborescence %v of weight %v is better than the solution of weight %v", treeStrings(sample.Edges), sample.Weight, naive.Weight)
+				}
+			}
+		}
+
+		// MSA reduces the graph to the same weight
+		arb, err := Solve(g, root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		feasible, err := MSA(g, root)
+		if err != nil || !feasible {
+			t.Fatalf("MSA returned %v (feasible: %v)", err, feasible)
+		}
+		if weight, err := TotalWeight(g); err != nil || weight != arb.Weight {
+			t.Fatalf("MSA reduced the graph to weight %v, expected %v (error: %v)", weight, arb.Weight, err)
+		}
+	})
+}
@@ -0,0 +1,237 @@
+package msa
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/gyuho/goraph"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update testdata/golden.json using the brute-force solver")
+
+const goldenPath = "testdata/golden.json"
+
+// goldenResult is the expected result of MSA for a graph and a root
+type goldenResult struct {
+	Feasible bool     `json:"feasible"`
+	Weight   float64  `json:"weight,omitempty"`
+	Tree     []string `json:"tree,omitempty"`
+
+	// Optimal is the number of arborescences of minimum weight, Tree only being compared when it is unique
+	Optimal int `json:"optimal,omitempty"`
+}
+
+// golden maps the ID of every graph of testdata/graph.json to the expected result for each of its roots
+type golden map[string]map[string]goldenResult
+
+// testGraphIDs lists the IDs of the graphs of testdata/graph.json
+func testGraphIDs() []string {
+	ids := make([]string, 18)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("graph_%02d", i)
+	}
+	return ids
+}
+
+// treeStrings returns the sorted representation of the given edges, "source target", as stored in the golden file
+func treeStrings(edges []goraph.Edge) []string {
+	strs := make([]string, len(edges))
+	for i, e := range edges {
+		strs[i] = e.Source().ID().String() + " " + e.Target().ID().String()
+	}
+	sort.Strings(strs)
+	return strs
+}
+
+// bruteForce finds the minimum spanning arborescences of g rooted at root by enumerating every choice of parent for each node
+// It takes exponential time, so it is only meant for graphs of about 10 nodes
+func bruteForce(t testing.TB, g goraph.Graph, root goraph.ID) goldenResult {
+	var nodes []goraph.ID
+	for id := range g.GetNodes() {
+		if id.String() != root.String() {
+			nodes = append(nodes, id)
+		}
+	}
+	sort.Sort(idsByString(nodes))
+
+	// The candidate incoming edges of every node
+	candidates := make([][]goraph.Edge, len(nodes))
+	for i, id := range nodes {
+		sources, err := g.GetSources(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		target, err := g.GetNode(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for sourceID, source := range sources {
+			if sourceID.String() == id.String() {
+				continue
+			}
+			weight, err := g.GetWeight(sourceID, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			candidates[i] = append(candidates[i], goraph.NewEdge(source, target, weight))
+		}
+		if len(candidates[i]) == 0 {
+			return goldenResult{}
+		}
+		sort.Sort(edgesByID(candidates[i]))
+	}
+
+	var best goldenResult
+	choice := make([]int, len(nodes))
+	parent := make(map[string]string, len(nodes))
+	for {
+		// Check that every node leads back to the root
+		edges := make([]goraph.Edge, len(nodes))
+		var weight float64
+		for i, c := range choice {
+			edges[i] = candidates[i][c]
+			parent[nodes[i].String()] = edges[i].Source().ID().String()
+			weight += edges[i].Weight()
+		}
+		spanning := true
+		for _, id := range nodes {
+			v := id.String()
+			for steps := 0; v != root.String() && spanning; steps++ {
+				spanning = steps < len(nodes)
+				v = parent[v]
+			}
+		}
+		if spanning {
+			switch {
+			case !best.Feasible || weight < best.Weight:
+				best = goldenResult{Feasible: true, Weight: weight, Tree: treeStrings(edges), Optimal: 1}
+			case weight == best.Weight:
+				best.Optimal++
+			}
+		}
+
+		// Next choice
+		i := 0
+		for ; i < len(choice); i++ {
+			choice[i]++
+			if choice[i] < len(candidates[i]) {
+				break
+			}
+			choice[i] = 0
+		}
+		if i == len(choice) {
+			return best
+		}
+	}
+}
+
+// loadGolden loads testdata/golden.json
+func loadGolden(t testing.TB) golden {
+	data, err := ioutil.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gold golden
+	if err := json.Unmarshal(data, &gold); err != nil {
+		t.Fatal(err)
+	}
+	return gold
+}
+
+// Test that the brute-force solver agrees with the golden file, or update it with -update
+func TestBruteForce_Golden(t *testing.T) {
+	computed := make(golden)
+	for _, graphID := range testGraphIDs() {
+		g := loadGraph(t, graphID)
+		computed[graphID] = make(map[string]goldenResult)
+		for root := range g.GetNodes() {
+			computed[graphID][root.String()] = bruteForce(t, g, root)
+		}
+	}
+
+	if *update {
+		data, err := json.MarshalIndent(computed, "", "\t")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(goldenPath, append(data, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	if gold := loadGolden(t); !reflect.DeepEqual(gold, computed) {
+		t.Errorf("The brute-force solver disagrees with %s, run go test -update to update it", goldenPath)
+	}
+}
+
+func TestMSA_Golden(t *testing.T) {
+	gold := loadGolden(t)
+	for _, graphID := range testGraphIDs() {
+		for root, expected := range gold[graphID] {
+			g := loadGraph(t, graphID)
+			feasible, err := MSA(g, goraph.StringID(root))
+			if !expected.Feasible {
+				if _, ok := err.(*InfeasibleError); !ok || feasible {
+					t.Errorf("%s rooted at %s: expected an *InfeasibleError, got %v (feasible: %v)", graphID, root, err, feasible)
+				}
+				continue
+			}
+			if err != nil || !feasible {
+				t.Errorf("%s rooted at %s: unexpected error %v (feasible: %v)", graphID, root, err, feasible)
+				continue
+			}
+			checkMSA(t, g, goraph.StringID(root), expected.Weight)
+
+			if expected.Optimal == 1 {
+				edges, err := GetEdges(g)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tree := treeStrings(edges); !reflect.DeepEqual(tree, expected.Tree) {
+					t.Errorf("%s rooted at %s: expected tree %v, got %v", graphID, root, expected.Tree, tree)
+				}
+			}
+		}
+	}
+}
+
+func TestMSAAllRoots_Golden(t *testing.T) {
+	gold := loadGolden(t)
+	for _, graphID := range testGraphIDs() {
+		// The best weight over every root
+		var (
+			anyFeasible bool
+			best        float64
+		)
+		for _, expected := range gold[graphID] {
+			if expected.Feasible && (!anyFeasible || expected.Weight < best) {
+				anyFeasible, best = true, expected.Weight
+			}
+		}
+
+		g := loadGraph(t, graphID)
+		feasible, tree, root, err := MSAAllRoots(g)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", graphID, err)
+			continue
+		}
+		if feasible != anyFeasible {
+			t.Errorf("%s: expected feasibility %v, got %v", graphID, anyFeasible, feasible)
+			continue
+		}
+		if !feasible {
+			continue
+		}
+		if expected := gold[graphID][root.String()]; !expected.Feasible || expected.Weight != best {
+			t.Errorf("%s: root %s isn't the best one, its arborescence weighs %v instead of %v", graphID, root, expected.Weight, best)
+		}
+		if weight, err := TotalWeight(tree); err != nil || weight != best {
+			t.Errorf("%s: expected weight %v, got %v (error: %v)", graphID, best, weight, err)
+		}
+	}
+}
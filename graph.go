@@ -0,0 +1,181 @@
+package msa
+
+import (
+	"fmt"
+	"github.com/gyuho/goraph"
+	"sort"
+)
+
+// Graph is the weighted directed graph the solvers consume, so that any storage can be used without being copied into a goraph.Graph
+// Adapters are provided for goraph.Graph with Goraph, for adjacency maps with Adjacency, and for gonum graphs in package msagonum
+type Graph interface {
+	// Nodes returns the IDs of every node
+	Nodes() []goraph.ID
+
+	// Incoming returns the edges going into the node with the given ID, along with their weights
+	Incoming(id goraph.ID) ([]goraph.Edge, error)
+}
+
+// goraphGraph adapts a goraph.Graph to Graph
+type goraphGraph struct {
+	g goraph.Graph
+}
+
+// Goraph adapts g to Graph
+func Goraph(g goraph.Graph) Graph {
+	return goraphGraph{g}
+}
+
+// Nodes returns the IDs of every node
+func (gg goraphGraph) Nodes() []goraph.ID {
+	nodes := gg.g.GetNodes()
+	ids := make([]goraph.ID, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Incoming returns the edges going into the node with the given ID
+func (gg goraphGraph) Incoming(id goraph.ID) ([]goraph.Edge, error) {
+	target, err := gg.g.GetNode(id)
+	if err != nil {
+		return nil, fmt.Errorf("Incoming: error while retrieving node %s: %v", id.String(), err)
+	}
+	sources, err := gg.g.GetSources(id)
+	if err != nil {
+		return nil, fmt.Errorf("Incoming: error while retrieving sources of %s: %v", id.String(), err)
+	}
+	edges := make([]goraph.Edge, 0, len(sources))
+	for sourceID, source := range sources {
+		weight, err := gg.g.GetWeight(sourceID, id)
+		if err != nil {
+			return nil, fmt.Errorf("Incoming: error while getting weight of edge going from %s to %s: %v", sourceID.String(), id.String(), err)
+		}
+		edges = append(edges, goraph.NewEdge(source, target, weight))
+	}
+	return edges, nil
+}
+
+// Adjacency is a Graph stored as a map from the ID of every node to the weights of the edges going into it, indexed by the ID of their source
+// Every node must be a key of the map, even those no edge goes into
+type Adjacency map[string]map[string]float64
+
+// Nodes returns the IDs of every node
+func (a Adjacency) Nodes() []goraph.ID {
+	ids := make([]goraph.ID, 0, len(a))
+	for id := range a {
+		ids = append(ids, goraph.StringID(id))
+	}
+	return ids
+}
+
+// Incoming returns the edges going into the node with the given ID
+func (a Adjacency) Incoming(id goraph.ID) ([]goraph.Edge, error) {
+	sources, ok := a[id.String()]
+	if !ok {
+		return nil, fmt.Errorf("Incoming: node %s isn't in the graph", id.String())
+	}
+	target := goraph.NewNode(id.String())
+	edges := make([]goraph.Edge, 0, len(sources))
+	for source, weight := range sources {
+		edges = append(edges, goraph.NewEdge(goraph.NewNode(source), target, weight))
+	}
+	return edges, nil
+}
+
+// LabeledEdge is an edge carrying a label, such as the relation of a dependency arc
+// Incoming may return several of them going from the same source, one per label: the solvers then keep the best one, and return it in the arborescence.
+type LabeledEdge interface {
+	goraph.Edge
+
+	// Label returns the label of the edge
+	Label() string
+}
+
+// labeledEdge implements LabeledEdge
+type labeledEdge struct {
+	goraph.Edge
+	label string
+}
+
+// NewLabeledEdge returns an edge going from source to target with the given weight and label
+func NewLabeledEdge(source goraph.Node, target goraph.Node, weight float64, label string) LabeledEdge {
+	return labeledEdge{goraph.NewEdge(source, target, weight), label}
+}
+
+// Label returns the label of the edge
+func (e labeledEdge) Label() string {
+	return e.label
+}
+
+// String returns a representation of the edge, along with its label
+func (e labeledEdge) String() string {
+	return fmt.Sprintf("%s -- %.3f (%s) -→ %s\n", e.Source(), e.Weight(), e.label, e.Target())
+}
+
+// LabeledAdjacency is like Adjacency, but every edge has several labeled alternatives: the weights of the edges going into a node are indexed by the ID of their source, then by their label
+// Every node must be a key of the map, even those no edge goes into
+type LabeledAdjacency map[string]map[string]map[string]float64
+
+// Nodes returns the IDs of every node
+func (a LabeledAdjacency) Nodes() []goraph.ID {
+	ids := make([]goraph.ID, 0, len(a))
+	for id := range a {
+		ids = append(ids, goraph.StringID(id))
+	}
+	return ids
+}
+
+// Incoming returns the edges going into the node with the given ID, one per label, as LabeledEdges
+// They are sorted by source and label, so that ties are broken the same way every time
+func (a LabeledAdjacency) Incoming(id goraph.ID) ([]goraph.Edge, error) {
+	sources, ok := a[id.String()]
+	if !ok {
+		return nil, fmt.Errorf("Incoming: node %s isn't in the graph", id.String())
+	}
+	target := goraph.NewNode(id.String())
+	var edges []goraph.Edge
+	for source, labels := range sources {
+		node := goraph.NewNode(source)
+		for label, weight := range labels {
+			edges = append(edges, NewLabeledEdge(node, target, weight, label))
+		}
+	}
+	sort.Sort(labeledEdges(edges))
+	return edges, nil
+}
+
+// labeledEdges sorts LabeledEdges by source ID, then label
+type labeledEdges []goraph.Edge
+
+func (s labeledEdges) Len() int { return len(s) }
+func (s labeledEdges) Less(i, j int) bool {
+	si, sj := s[i].Source().ID().String(), s[j].Source().ID().String()
+	return si < sj || (si == sj && s[i].(LabeledEdge).Label() < s[j].(LabeledEdge).Label())
+}
+func (s labeledEdges) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+// hasNode returns whether g has a node with the given ID
+func hasNode(g Graph, id goraph.ID) bool {
+	for _, node := range g.Nodes() {
+		if node.String() == id.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// hasEdge returns whether g has an edge going from source to target
+func hasEdge(g Graph, source goraph.ID, target goraph.ID) bool {
+	edges, err := g.Incoming(target)
+	if err != nil {
+		return false
+	}
+	for _, e := range edges {
+		if e.Source().ID().String() == source.String() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package msa
+
+import (
+	"fmt"
+	"github.com/gyuho/goraph"
+	"math"
+	"testing"
+)
+
+// adjacencyOf copies g into an Adjacency
+func adjacencyOf(t testing.TB, g goraph.Graph) Adjacency {
+	a := make(Adjacency)
+	for id := range g.GetNodes() {
+		a[id.String()] = make(map[string]float64)
+	}
+	edges, err := GetEdges(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range edges {
+		a[e.Target().ID().String()][e.Source().ID().String()] = e.Weight()
+	}
+	return a
+}
+
+// Test that solving through every adapter gives the same result as Solve
+func TestSolveGraph_Adapters(t *testing.T) {
+	for i := 0; i <= 17; i++ {
+		graphID := fmt.Sprintf("graph_%02d", i)
+		g := loadGraph(t, graphID)
+		for root := range g.GetNodes() {
+			expected, expectedErr := Solve(g, root, WithAlgorithm(Tarjan))
+			for _, alg := range []Algorithm{Naive, Tarjan} {
+				for _, adapted := range []Graph{Goraph(g), adjacencyOf(t, g)} {
+					arb, err := SolveGraph(adapted, goraph.StringID(root.String()), WithAlgorithm(alg))
+					if (err == nil) != (expectedErr == nil) {
+						t.Errorf("%s rooted at %s: expected error %v, got %v", graphID, root, expectedErr, err)
+						continue
+					}
+					if err != nil {
+						continue
+					}
+					if arb.Weight != expected.Weight {
+						t.Errorf("%s rooted at %s with %v on %T: expected weight %v, got %v", graphID, root, alg, adapted, expected.Weight, arb.Weight)
+					}
+				}
+			}
+		}
+	}
+}
+
+// Test that the Graph variants of the other solvers give the same results as the goraph.Graph ones
+func TestGraphVariants_Adapters(t *testing.T) {
+	for _, graphID := range testGraphIDs() {
+		g := loadGraph(t, graphID)
+		a := adjacencyOf(t, g)
+
+		expectedAll, expectedErr := SolveAllRoots(g)
+		all, err := SolveAllRootsGraph(a)
+		if err != expectedErr && (err == nil || expectedErr == nil) {
+			t.Errorf("%s: SolveAllRootsGraph returned error %v, expected %v", graphID, err, expectedErr)
+		} else if err == nil && all.Weight != expectedAll.Weight {
+			t.Errorf("%s: SolveAllRootsGraph returned weight %v, expected %v", graphID, all.Weight, expectedAll.Weight)
+		}
+
+		opts := []Option{WithRootCost(50), WithMaxRoots(2)}
+		expectedB, expectedErr := MSB(g, opts...)
+		b, err := MSBGraph(a, opts...)
+		if (err == nil) != (expectedErr == nil) {
+			t.Errorf("%s: MSBGraph returned error %v, expected %v", graphID, err, expectedErr)
+		} else if err == nil && (b.Weight != expectedB.Weight || len(b.Roots) != len(expectedB.Roots)) {
+			t.Errorf("%s: MSBGraph returned %d roots and weight %v, expected %d and %v", graphID, len(b.Roots), b.Weight, len(expectedB.Roots), expectedB.Weight)
+		}
+
+		for root := range g.GetNodes() {
+			id := goraph.StringID(root.String())
+			expectedK, expectedErr := KBest(g, root, 3)
+			k, err := KBestGraph(a, id, 3)
+			if (err == nil) != (expectedErr == nil) {
+				t.Errorf("%s rooted at %s: KBestGraph returned error %v, expected %v", graphID, root, err, expectedErr)
+				continue
+			}
+			if err != nil {
+				continue
+			}
+			if len(k) != len(expectedK) {
+				t.Errorf("%s rooted at %s: KBestGraph returned %d arborescences, expected %d", graphID, root, len(k), len(expectedK))
+			}
+			for i := 0; i < len(k) && i < len(expectedK); i++ {
+				if k[i].Weight != expectedK[i].Weight {
+					t.Errorf("%s rooted at %s: KBestGraph returned weight %v at rank %d, expected %v", graphID, root, k[i].Weight, i, expectedK[i].Weight)
+				}
+			}
+
+			expectedCount, err := CountArborescences(g, root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			count, err := CountArborescencesGraph(a, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count.Cmp(expectedCount) != 0 {
+				t.Errorf("%s rooted at %s: CountArborescencesGraph returned %v, expected %v", graphID, root, count, expectedCount)
+			}
+
+			expectedLogZ, expectedErr := LogPartition(g, root)
+			logZ, err := LogPartitionGraph(a, id)
+			if (err == nil) != (expectedErr == nil) {
+				t.Errorf("%s rooted at %s: LogPartitionGraph returned error %v, expected %v", graphID, root, err, expectedErr)
+			} else if err == nil && math.Abs(logZ-expectedLogZ) > 1e-9*(1+math.Abs(expectedLogZ)) {
+				t.Errorf("%s rooted at %s: LogPartitionGraph returned %v, expected %v", graphID, root, logZ, expectedLogZ)
+			}
+
+			arb, err := Solve(g, root, WithDuals())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := VerifyGraph(a, id, arb); err != nil {
+				t.Errorf("%s rooted at %s: %v", graphID, root, err)
+			}
+		}
+	}
+}
+
+func TestSolveGraph_Adjacency(t *testing.T) {
+	a := Adjacency{
+		"root": {},
+		"A":    {"root": 5, "B": 1},
+		"B":    {"root": 4, "A": 1},
+		"C":    {"A": 2, "B": 3, "C": -10},
+	}
+	arb, err := SolveGraph(a, goraph.StringID("root"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if arb.Weight != 7 {
+		t.Errorf("Expected weight 7, got %v", arb.Weight)
+	}
+	if source := arb.Parent[goraph.StringID("C")].Source().ID().String(); source != "A" {
+		t.Errorf("Expected C to come from A, got %s", source)
+	}
+
+	if _, err := SolveGraph(a, goraph.StringID("D")); err == nil {
+		t.Error("Expected an error for an unknown root")
+	}
+
+	a["C"]["D"] = 1
+	if _, err := SolveGraph(a, goraph.StringID("root"), WithAlgorithm(Tarjan)); err == nil {
+		t.Error("Expected an error for an edge coming from an unknown node")
+	}
+}
+
+// Test that the best label of every edge is selected, and returned in the arborescence
+func TestSolveGraph_Labeled(t *testing.T) {
+	for _, graphID := range testGraphIDs() {
+		g := loadGraph(t, graphID)
+		la := make(LabeledAdjacency)
+		for target, sources := range adjacencyOf(t, g) {
+			la[target] = make(map[string]map[string]float64, len(sources))
+			for source, weight := range sources {
+				la[target][source] = map[string]float64{"a": weight + 1, "b": weight, "c": weight + 2, "d": weight + 2}
+			}
+		}
+		for root := range g.GetNodes() {
+			for _, test := range []struct {
+				objective Objective
+				label     string // the best label, d tying with c but coming after it
+				shift     float64
+			}{{Minimize, "b", 0}, {Maximize, "c", 2}} {
+				expected, expectedErr := Solve(g, root, WithObjective(test.objective))
+				for _, alg := range []Algorithm{Naive, Tarjan} {
+					arb, err := SolveGraph(la, root, WithObjective(test.objective), WithAlgorithm(alg))
+					if (err == nil) != (expectedErr == nil) {
+						t.Fatalf("%s rooted at %s: got error %v, expected %v", graphID, root, err, expectedErr)
+					}
+					if err != nil {
+						continue
+					}
+					if weight := expected.Weight + test.shift*float64(len(arb.Edges)); arb.Weight != weight {
+						t.Errorf("%s rooted at %s (%v, %v): got weight %v, expected %v", graphID, root, test.objective, alg, arb.Weight, weight)
+					}
+					for _, e := range arb.Edges {
+						le, ok := e.(LabeledEdge)
+						if !ok {
+							t.Fatalf("%s rooted at %s: edge %s isn't labeled", graphID, root, e)
+						}
+						if le.Label() != test.label {
+							t.Errorf("%s rooted at %s (%v, %v): edge %s has label %s, expected %s", graphID, root, test.objective, alg, e, le.Label(), test.label)
+						}
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package msa
+
+import (
+	"container/heap"
+	"fmt"
+	"github.com/gyuho/goraph"
+	"sort"
+)
+
+// KBest returns the k Minimum Spanning Arborescences of g rooted at root, ranked from the lightest (or heaviest with WithObjective(Maximize)).
+// Fewer are returned if g doesn't have k of them. g isn't modified.
+//
+// It follows the partitioning scheme of Lawler ("A procedure for computing the K best solutions to discrete optimization problems", Management Science, 1972), after Murty's for assignments:
+// the arborescences of a subproblem, defined by required and forbidden edges, are split among new subproblems by excluding the best one's edges one at a time,
+// so that every arborescence is found exactly once, and the next best one is always the best of a pending subproblem.
+// Every new subproblem is solved from scratch, so that up to V-1 solves are needed per arborescence returned, that is O(k·V·E log V) with Tarjan.
+func KBest(g goraph.Graph, root goraph.ID, k int, opts ...Option) ([]*Arborescence, error) {
+	ranked, err := kBest(Goraph(g), root, k, opts)
+	switch err.(type) {
+	case nil, *InfeasibleError, *ConstraintError:
+		return ranked, err
+	default:
+		return nil, fmt.Errorf("KBest: %v", err)
+	}
+}
+
+// KBestGraph is like KBest, but works on any Graph
+// Of several edges going from a node to another, only the best one is considered, as by SolveGraph.
+func KBestGraph(g Graph, root goraph.ID, k int, opts ...Option) ([]*Arborescence, error) {
+	ranked, err := kBest(g, root, k, opts)
+	switch err.(type) {
+	case nil, *InfeasibleError, *ConstraintError:
+		return ranked, err
+	default:
+		return nil, fmt.Errorf("KBestGraph: %v", err)
+	}
+}
+
+// kBest implements KBest and KBestGraph
+func kBest(g Graph, root goraph.ID, k int, opts []Option) ([]*Arborescence, error) {
+	if k < 0 {
+		return nil, fmt.Errorf("invalid number of arborescences %d", k)
+	}
+	if !hasNode(g, root) {
+		return nil, fmt.Errorf("root %s isn't in the graph", root.String())
+	}
+	c := newConfig(opts)
+	opts = opts[:len(opts):len(opts)] // so that appending to it copies it
+	seq := 0
+
+	// solveSubproblem solves the subproblem with the given constraints, returning nil if it is infeasible
+	solveSubproblem := func(required []goraph.Edge, forbidden []goraph.Edge) (*subproblem, error) {
+		arb, err := solve(g, root, newConfig(append(opts, WithRequired(required...), WithForbidden(forbidden...))))
+		if _, ok := err.(*InfeasibleError); ok {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		seq++
+		return &subproblem{
+			required:  required,
+			forbidden: forbidden,
+			arb:       arb,
+			key:       c.sign() * arb.Weight,
+			seq:       seq,
+		}, nil
+	}
+
+	// The best arborescence, with the user's constraints only
+	first, err := solve(g, root, c)
+	if err != nil {
+		return nil, err
+	}
+	var userRequired []goraph.Edge
+	for _, e := range c.required {
+		userRequired = append(userRequired, e)
+	}
+	queue := &subproblemQueue{{required: userRequired, arb: first, key: c.sign() * first.Weight}}
+
+	ranked := make([]*Arborescence, 0, k)
+	for len(ranked) < k && queue.Len() != 0 {
+		sp := heap.Pop(queue).(*subproblem)
+		ranked = append(ranked, sp.arb)
+		if len(ranked) == k {
+			break
+		}
+
+		// Partition the remaining arborescences of the subproblem: the i-th new subproblem requires the first i-1 free edges of the best arborescence, and forbids the i-th
+		required := make(map[edgeID]struct{}, len(sp.required))
+		for _, e := range sp.required {
+			required[edgeKey(e.Source().ID(), e.Target().ID())] = struct{}{}
+		}
+		var free []goraph.Edge
+		for _, e := range sp.arb.Edges {
+			if _, ok := required[edgeKey(e.Source().ID(), e.Target().ID())]; !ok {
+				free = append(free, e)
+			}
+		}
+		sort.Sort(edgesByID(free))
+
+		for i, e := range free {
+			nr := make([]goraph.Edge, len(sp.required), len(sp.required)+i)
+			copy(nr, sp.required)
+			nr = append(nr, free[:i]...)
+			nf := make([]goraph.Edge, len(sp.forbidden), len(sp.forbidden)+1)
+			copy(nf, sp.forbidden)
+			nf = append(nf, e)
+
+			nsp, err := solveSubproblem(nr, nf)
+			if err != nil {
+				return nil, err
+			}
+			if nsp != nil {
+				heap.Push(queue, nsp)
+			}
+		}
+	}
+
+	return ranked, nil
+}
+
+// subproblem is a set of arborescences defined by required and forbidden edges, along with the best of them
+type subproblem struct {
+	required  []goraph.Edge
+	forbidden []goraph.Edge
+	arb       *Arborescence
+	key       float64 // weight of arb, as minimized
+	seq       int     // insertion order, to break ties
+}
+
+// subproblemQueue is a priority queue of subproblems, ordered by the weight of their best arborescence
+type subproblemQueue []*subproblem
+
+func (q subproblemQueue) Len() int { return len(q) }
+func (q subproblemQueue) Less(i, j int) bool {
+	return q[i].key < q[j].key || (q[i].key == q[j].key && q[i].seq < q[j].seq)
+}
+func (q subproblemQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *subproblemQueue) Push(x interface{}) {
+	*q = append(*q, x.(*subproblem))
+}
+func (q *subproblemQueue) Pop() interface{} {
+	old := *q
+	sp := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return sp
+}
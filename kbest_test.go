@@ -0,0 +1,80 @@
+package msa
+
+import (
+	"github.com/gyuho/goraph"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestKBest(t *testing.T) {
+	tests := []struct {
+		graphID string
+		root    string
+		k       int
+		obj     Objective
+		weights []float64
+	}{
+		// graph_17 only has 3 arborescences rooted at D
+		{"graph_17", "D", 5, Minimize, []float64{15, 17, 19}},
+		{"graph_00", "S", 12, Minimize, []float64{75, 77, 80, 82, 84, 85, 85, 86, 87, 87, 87, 88}},
+		{"graph_12", "B", 9, Minimize, []float64{-13, -5, -3, 0, 2, 8, 10, 10, 12}},
+		{"graph_12", "B", 3, Maximize, []float64{12, 10, 10}},
+		{"graph_12", "B", 0, Minimize, []float64{}},
+	}
+
+	for _, test := range tests {
+		g := loadGraph(t, test.graphID)
+		for _, alg := range []Algorithm{Naive, Tarjan} {
+			ranked, err := KBest(g, goraph.StringID(test.root), test.k, WithAlgorithm(alg), WithObjective(test.obj))
+			if err != nil {
+				t.Errorf("%s rooted at %s (%v): unexpected error: %v", test.graphID, test.root, alg, err)
+				continue
+			}
+			weights := make([]float64, len(ranked))
+			for i, arb := range ranked {
+				weights[i] = arb.Weight
+			}
+			if len(weights) != len(test.weights) {
+				t.Errorf("%s rooted at %s (%v): expected weights %v, got %v", test.graphID, test.root, alg, test.weights, weights)
+				continue
+			}
+			for i := range weights {
+				if weights[i] != test.weights[i] {
+					t.Errorf("%s rooted at %s (%v): expected weights %v, got %v", test.graphID, test.root, alg, test.weights, weights)
+					break
+				}
+			}
+		}
+	}
+}
+
+// Test that ties don't make KBest return the same arborescence twice
+func TestKBest_Distinct(t *testing.T) {
+	// graph_08 has 70 arborescences rooted at A, all of weight 7
+	g := loadGraph(t, "graph_08")
+	ranked, err := KBest(g, goraph.StringID("A"), 100, WithAlgorithm(Tarjan))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranked) != 70 {
+		t.Fatalf("Expected 70 arborescences, got %d", len(ranked))
+	}
+
+	seen := make(map[string]struct{}, len(ranked))
+	for _, arb := range ranked {
+		if arb.Weight != 7 {
+			t.Errorf("Expected a weight of 7, got %v", arb.Weight)
+		}
+		edges := make([]string, len(arb.Edges))
+		for i, e := range arb.Edges {
+			edges[i] = e.Source().ID().String() + e.Target().ID().String()
+		}
+		sort.Strings(edges)
+		key := strings.Join(edges, " ")
+		if _, ok := seen[key]; ok {
+			t.Errorf("Arborescence %s returned twice", key)
+		}
+		seen[key] = struct{}{}
+	}
+}
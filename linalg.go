@@ -0,0 +1,151 @@
+package msa
+
+import (
+	"math"
+	"math/big"
+)
+
+// luDecomposition is the LU decomposition with partial pivoting of a square matrix, both factors being stored in the same matrix
+type luDecomposition struct {
+	lu   [][]float64
+	perm []int // row i of the factors is row perm[i] of the original matrix
+	sign float64
+}
+
+// newLUDecomposition decomposes a, which it overwrites
+// It returns false if a is singular
+func newLUDecomposition(a [][]float64) (*luDecomposition, bool) {
+	n := len(a)
+	d := &luDecomposition{lu: a, perm: make([]int, n), sign: 1}
+	for i := range d.perm {
+		d.perm[i] = i
+	}
+
+	for k := 0; k < n; k++ {
+		// Pick the largest pivot in the column
+		p := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(a[i][k]) > math.Abs(a[p][k]) {
+				p = i
+			}
+		}
+		if a[p][k] == 0 {
+			return nil, false
+		}
+		if p != k {
+			a[p], a[k] = a[k], a[p]
+			d.perm[p], d.perm[k] = d.perm[k], d.perm[p]
+			d.sign = -d.sign
+		}
+
+		for i := k + 1; i < n; i++ {
+			a[i][k] /= a[k][k]
+			f := a[i][k]
+			if f == 0 {
+				continue
+			}
+			for j := k + 1; j < n; j++ {
+				a[i][j] -= f * a[k][j]
+			}
+		}
+	}
+	return d, true
+}
+
+// logDet returns the logarithm of the absolute value of the determinant, and its sign
+func (d *luDecomposition) logDet() (float64, float64) {
+	var logDet float64
+	sign := d.sign
+	for i := range d.lu {
+		pivot := d.lu[i][i]
+		if pivot < 0 {
+			sign = -sign
+		}
+		logDet += math.Log(math.Abs(pivot))
+	}
+	return logDet, sign
+}
+
+// bareissDet returns the exact determinant of a square integer matrix, using the fraction-free Bareiss algorithm
+// a is overwritten
+func bareissDet(a [][]*big.Int) *big.Int {
+	n := len(a)
+	if n == 0 {
+		return big.NewInt(1)
+	}
+
+	sign := 1
+	prev := big.NewInt(1)
+	tmp := new(big.Int)
+	for k := 0; k < n-1; k++ {
+		// Find a non-zero pivot
+		if a[k][k].Sign() == 0 {
+			p := -1
+			for i := k + 1; i < n; i++ {
+				if a[i][k].Sign() != 0 {
+					p = i
+					break
+				}
+			}
+			if p < 0 {
+				return big.NewInt(0)
+			}
+			a[p], a[k] = a[k], a[p]
+			sign = -sign
+		}
+
+		for i := k + 1; i < n; i++ {
+			for j := k + 1; j < n; j++ {
+				// a[i][j] = (a[i][j]*a[k][k] - a[i][k]*a[k][j]) / prev, which is exact
+				a[i][j].Mul(a[i][j], a[k][k])
+				tmp.Mul(a[i][k], a[k][j])
+				a[i][j].Sub(a[i][j], tmp)
+				a[i][j].Quo(a[i][j], prev)
+			}
+		}
+		prev = a[k][k]
+	}
+
+	det := new(big.Int).Set(a[n-1][n-1])
+	if sign < 0 {
+		det.Neg(det)
+	}
+	return det
+}
+
+// inverse returns the inverse of the decomposed matrix
+func (d *luDecomposition) inverse() [][]float64 {
+	n := len(d.lu)
+	inv := make([][]float64, n)
+	for i := range inv {
+		inv[i] = make([]float64, n)
+	}
+
+	// Solve LUx = Pe_j for every column j
+	x := make([]float64, n)
+	for j := 0; j < n; j++ {
+		for i := 0; i < n; i++ {
+			x[i] = 0
+			if d.perm[i] == j {
+				x[i] = 1
+			}
+		}
+		// Forward substitution, L having a unit diagonal
+		for i := 0; i < n; i++ {
+			for k := 0; k < i; k++ {
+				x[i] -= d.lu[i][k] * x[k]
+			}
+		}
+		// Backward substitution
+		for i := n - 1; i >= 0; i-- {
+			for k := i + 1; k < n; k++ {
+				x[i] -= d.lu[i][k] * x[k]
+			}
+			x[i] /= d.lu[i][i]
+		}
+		for i := 0; i < n; i++ {
+			inv[i][j] = x[i]
+		}
+	}
+	return inv
+}
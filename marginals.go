@@ -0,0 +1,89 @@
+package msa
+
+import (
+	"fmt"
+	"github.com/gyuho/goraph"
+	"math"
+)
+
+// Marginals returns, for every edge of g, the probability that it is part of a random spanning arborescence rooted at root,
+// arborescences being drawn with a probability proportional to the product of their edge scores, as defined by LogPartition.
+// The map is keyed by the edges returned by GetEdges, edges that can't be part of an arborescence having a probability of 0.
+// Following Koo et al. (2007) and Smith & Smith (2007), marginals are computed from the inverse of the Laplacian.
+// If some nodes can't be reached from root, the returned error is an *InfeasibleError listing them.
+func Marginals(g goraph.Graph, root goraph.ID, opts ...Option) (map[goraph.Edge]float64, error) {
+	d, m, err := marginals(Goraph(g), root, newConfig(opts))
+	switch err.(type) {
+	case nil:
+	case *InfeasibleError, *ConstraintError:
+		return nil, err
+	default:
+		return nil, fmt.Errorf("Marginals: %v", err)
+	}
+	byPair := make(map[edgeID]float64, len(d.edges))
+	for i, e := range d.edges {
+		byPair[edgeKey(e.Source().ID(), e.Target().ID())] = m[i]
+	}
+
+	edges, err := GetEdges(g)
+	if err != nil {
+		return nil, fmt.Errorf("Marginals: error while retrieving edges: %v", err)
+	}
+	res := make(map[goraph.Edge]float64, len(edges))
+	for _, e := range edges {
+		res[e] = byPair[edgeKey(e.Source().ID(), e.Target().ID())]
+	}
+	return res, nil
+}
+
+// MarginalsGraph is like Marginals, but works on any Graph
+// The map is keyed by the edges returned by Incoming, but for self-loops, so that every one of several edges going from a node to another has its own probability.
+func MarginalsGraph(g Graph, root goraph.ID, opts ...Option) (map[goraph.Edge]float64, error) {
+	d, m, err := marginals(g, root, newConfig(opts))
+	switch err.(type) {
+	case nil:
+	case *InfeasibleError, *ConstraintError:
+		return nil, err
+	default:
+		return nil, fmt.Errorf("MarginalsGraph: %v", err)
+	}
+	res := make(map[goraph.Edge]float64, len(d.edges))
+	for i, e := range d.edges {
+		res[e] = m[i]
+	}
+	return res, nil
+}
+
+// marginals implements Marginals and MarginalsGraph, returning the digraph of g along with the marginal of each of its input edges
+func marginals(g Graph, root goraph.ID, c *config) (*digraph, []float64, error) {
+	d, r, err := newConstrainedDigraph(g, root, c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	l, _ := d.laplacian(r, c.sign())
+	lu, ok := newLUDecomposition(l)
+	if !ok {
+		return nil, nil, fmt.Errorf("the Laplacian is singular")
+	}
+	inv := lu.inverse()
+
+	// The marginal of the arc going from u to v is s(u,v) * (inv[v][v] - inv[v][u]), the second term being absent when u is the root
+	// Scores being scaled by column in the Laplacian, the same scaling must be applied here
+	row := d.rows(r)
+	max := d.maxLogScores(c.sign())
+	marginals := make([]float64, len(d.edges))
+	for _, a := range d.arcs {
+		if a.to == r {
+			continue
+		}
+		v := row[a.to]
+		m := inv[v][v]
+		if a.from != r {
+			m -= inv[v][row[a.from]]
+		}
+		m *= math.Exp(-c.sign()*a.weight - max[a.to])
+		marginals[a.edge] = m
+	}
+	return d, marginals, nil
+}
@@ -0,0 +1,86 @@
+package msa
+
+import (
+	"github.com/gyuho/goraph"
+	"math"
+	"testing"
+)
+
+// Test Marginals against the enumeration of every arborescence by KBest
+func TestMarginals(t *testing.T) {
+	tests := []struct {
+		graphID string
+		root    string
+	}{
+		{"graph_17", "D"},
+		{"graph_12", "B"},
+		{"graph_08", "A"},
+		{"graph_14", "A"},
+	}
+
+	for _, test := range tests {
+		g := loadGraph(t, test.graphID)
+		root := goraph.StringID(test.root)
+		for _, obj := range []Objective{Minimize, Maximize} {
+			marginals, err := Marginals(g, root, WithObjective(obj))
+			if err != nil {
+				t.Fatal(err)
+			}
+			logZ, err := LogPartition(g, root, WithObjective(obj))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Sum the probability of every arborescence containing each edge
+			all, err := KBest(g, root, 1000, WithAlgorithm(Tarjan), WithObjective(obj))
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := make(map[edgeID]float64)
+			for _, arb := range all {
+				logScore := arb.Weight
+				if obj == Minimize {
+					logScore = -arb.Weight
+				}
+				p := math.Exp(logScore - logZ)
+				for _, e := range arb.Edges {
+					expected[edgeKey(e.Source().ID(), e.Target().ID())] += p
+				}
+			}
+
+			incoming := make(map[string]float64)
+			for e, m := range marginals {
+				key := edgeKey(e.Source().ID(), e.Target().ID())
+				if math.Abs(m-expected[key]) > 1e-9 {
+					t.Errorf("%s rooted at %s (%v): edge %s has marginal %v, expected %v", test.graphID, test.root, obj, e, m, expected[key])
+				}
+				incoming[key.target] += m
+			}
+
+			// Every node but the root has exactly one parent
+			for id := range g.GetNodes() {
+				expected := 1.0
+				if id.String() == test.root {
+					expected = 0
+				}
+				if math.Abs(incoming[id.String()]-expected) > 1e-9 {
+					t.Errorf("%s rooted at %s (%v): marginals going to %s sum to %v", test.graphID, test.root, obj, id, incoming[id.String()])
+				}
+			}
+		}
+	}
+}
+
+func TestMarginals_Infeasible(t *testing.T) {
+	g := loadGraph(t, "graph_05")
+	if _, err := Marginals(g, goraph.StringID("A")); err == nil {
+		t.Error("Expected an error for an infeasible graph")
+	} else if _, ok := err.(*InfeasibleError); !ok {
+		t.Errorf("Expected an *InfeasibleError, got %T: %v", err, err)
+	}
+	if _, err := MarginalsGraph(Goraph(g), goraph.StringID("A")); err == nil {
+		t.Error("Expected an error for an infeasible graph")
+	} else if _, ok := err.(*InfeasibleError); !ok {
+		t.Errorf("Expected an *InfeasibleError, got %T: %v", err, err)
+	}
+}
@@ -0,0 +1,254 @@
+package msa
+
+import (
+	"fmt"
+	"github.com/gyuho/goraph"
+	"math"
+	"strconv"
+)
+
+// This file implements a dense version of Chu–Liu/Edmonds' algorithm in O(n²), suited to complete graphs such as those scored by dependency parsers
+// It walks backwards along the lightest incoming arcs like tarjan does, but finds them by scanning a column of the weight matrix instead of using heaps,
+// and contracts a cycle by merging the rows and columns of its nodes into those of one of them.
+// See R. E. Tarjan, "Finding optimum branchings", Networks, 1977
+
+// MSAMatrix computes the Minimum Spanning Arborescence rooted at root of the complete graph whose arc going from h to d has weight scores[h][d].
+// Use WithObjective(Maximize) for scores that are higher for better arcs, as is the case with most dependency parsers.
+// Infinite entries mark absent arcs, and the diagonal as well as the root's column are ignored. Other options are ignored.
+// It returns the parent of every node, -1 for the root. If some nodes can't be reached from root, the error is an *InfeasibleError whose IDs are their indexes.
+func MSAMatrix(scores [][]float64, root int, opts ...Option) ([]int, error) {
+	n := len(scores)
+	flat := make([]float64, n*n)
+	for h, row := range scores {
+		if len(row) != n {
+			return nil, fmt.Errorf("MSAMatrix: row %d has %d entries instead of %d", h, len(row), n)
+		}
+		copy(flat[h*n:], row)
+	}
+	heads, err := msaMatrix(flat, n, root, newConfig(opts))
+	if _, ok := err.(*InfeasibleError); ok {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("MSAMatrix: %v", err)
+	}
+	return heads, nil
+}
+
+// MSAMatrixFlat is like MSAMatrix, the weight of the arc going from h to d being scores[h*n+d]
+// scores isn't modified
+func MSAMatrixFlat(scores []float64, n int, root int, opts ...Option) ([]int, error) {
+	if len(scores) != n*n {
+		return nil, fmt.Errorf("MSAMatrixFlat: expected %d scores, got %d", n*n, len(scores))
+	}
+	flat := make([]float64, n*n)
+	copy(flat, scores)
+	heads, err := msaMatrix(flat, n, root, newConfig(opts))
+	if _, ok := err.(*InfeasibleError); ok {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("MSAMatrixFlat: %v", err)
+	}
+	return heads, nil
+}
+
+// msaMatrix implements MSAMatrix and MSAMatrixFlat, w being the n×n weight matrix it works on
+func msaMatrix(w []float64, n int, root int, c *config) ([]int, error) {
+	if root < 0 || root >= n {
+		return nil, fmt.Errorf("root %d isn't in the graph of %d nodes", root, n)
+	}
+	switch c.objective {
+	case Minimize, Maximize:
+	default:
+		return nil, fmt.Errorf("unknown objective %v", c.objective)
+	}
+
+	// Normalize the matrix: absent arcs weigh +Inf, including those going into the root or from a node to itself
+	sign := c.sign()
+	for i, weight := range w {
+		h, d := i/n, i%n
+		switch {
+		case math.IsNaN(weight):
+			return nil, fmt.Errorf("arc going from %d to %d has weight NaN", h, d)
+		case math.IsInf(weight, 0) || h == d || d == root:
+			w[i] = math.Inf(1)
+		default:
+			w[i] = sign * weight
+		}
+	}
+	if unreachable := denseUnreachable(w, n, root); len(unreachable) != 0 {
+		return nil, &InfeasibleError{Root: goraph.StringID(strconv.Itoa(root)), Unreachable: unreachable}
+	}
+
+	in, ok := denseEdmonds(w, n, root)
+	if !ok {
+		return nil, fmt.Errorf("no spanning arborescence is rooted at %d", root)
+	}
+	heads := make([]int, n)
+	for v, a := range in {
+		heads[v] = a / n
+	}
+	heads[root] = -1
+	return heads, nil
+}
+
+// denseUnreachable returns the indexes of the nodes that can't be reached from root, as IDs
+func denseUnreachable(w []float64, n int, root int) []goraph.ID {
+	reached := make([]bool, n)
+	reached[root] = true
+	stack := []int{root}
+	for len(stack) != 0 {
+		u := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for v := 0; v < n; v++ {
+			if !reached[v] && !math.IsInf(w[u*n+v], 1) {
+				reached[v] = true
+				stack = append(stack, v)
+			}
+		}
+	}
+
+	var unreachable []goraph.ID
+	for v, ok := range reached {
+		if !ok {
+			unreachable = append(unreachable, goraph.StringID(strconv.Itoa(v)))
+		}
+	}
+	return unreachable
+}
+
+// denseEdmonds computes the Minimum Spanning Arborescence of the n×n weight matrix w rooted at root, +Inf marking absent arcs
+// It returns the arc going into every node, h*n+d for the arc going from h to d, or false if no spanning arborescence exists
+// w is used as scratch space: row and column u hold the reduced weights of the arcs leaving and entering supernode u,
+// whose original arcs are kept in arcs
+func denseEdmonds(w []float64, n int, root int) ([]int, bool) {
+	arcs := make([]int, n*n)
+	for i := range arcs {
+		arcs[i] = i
+	}
+	uf := newRollbackUnionFind(n)
+	active := make([]bool, n)
+	for i := range active {
+		active[i] = true
+	}
+
+	seen := make([]int, n)
+	for i := range seen {
+		seen[i] = -1
+	}
+	seen[root] = root
+	chosen := make([]float64, n) // reduced weight of the arc chosen for every supernode
+	in := make([]int, n)
+	for i := range in {
+		in[i] = -1
+	}
+	column := make([]float64, n)
+	columnArcs := make([]int, n)
+
+	var (
+		path         []int
+		queue        []int
+		contractions []contraction
+	)
+	for s := 0; s < n; s++ {
+		path, queue = path[:0], queue[:0]
+		u := uf.find(s)
+		for seen[u] < 0 {
+			// Select the lightest arc going into u
+			best := -1
+			for x := 0; x < n; x++ {
+				if active[x] && x != u && (best < 0 || w[x*n+u] < w[best*n+u]) {
+					best = x
+				}
+			}
+			if best < 0 || math.IsInf(w[best*n+u], 1) {
+				return nil, false
+			}
+			chosen[u] = w[best*n+u]
+			queue = append(queue, arcs[best*n+u])
+			path = append(path, u)
+			seen[u] = s
+
+			u = best
+			if seen[u] != s {
+				continue
+			}
+
+			// We found a cycle, merge its supernodes into one, whose incoming arcs are reduced by the weight of the cycle arc they would replace
+			var (
+				end     = len(queue)
+				t       = uf.time()
+				members []int
+				x       int
+			)
+			for {
+				x, path = path[len(path)-1], path[:len(path)-1]
+				members = append(members, x)
+				if x == u {
+					break
+				}
+			}
+			start := len(path)
+			cycle := make([]int, end-start)
+			copy(cycle, queue[start:end])
+			queue = queue[:start]
+
+			for y := 0; y < n; y++ {
+				column[y], columnArcs[y] = math.Inf(1), -1
+			}
+			for _, m := range members {
+				for y := 0; y < n; y++ {
+					if reduced := w[y*n+m] - chosen[m]; active[y] && reduced < column[y] {
+						column[y], columnArcs[y] = reduced, arcs[y*n+m]
+					}
+				}
+			}
+			for _, m := range members {
+				uf.union(u, m)
+			}
+			rep := uf.find(u)
+			for _, m := range members {
+				if m == rep {
+					continue
+				}
+				active[m] = false
+				// The arcs leaving the cycle go from rep, keeping the lightest of the parallel ones
+				for y := 0; y < n; y++ {
+					if w[m*n+y] < w[rep*n+y] {
+						w[rep*n+y], arcs[rep*n+y] = w[m*n+y], arcs[m*n+y]
+					}
+				}
+			}
+			for y := 0; y < n; y++ {
+				w[y*n+rep], arcs[y*n+rep] = column[y], columnArcs[y]
+			}
+			for _, m := range members {
+				w[m*n+rep] = math.Inf(1)
+				w[rep*n+m] = math.Inf(1)
+			}
+
+			u = rep
+			seen[u] = -1
+			contractions = append(contractions, contraction{node: u, time: t, arcs: cycle})
+		}
+
+		for _, a := range queue {
+			in[uf.find(a%n)] = a
+		}
+	}
+
+	// Expand the cycles, the most recent first
+	for i := len(contractions) - 1; i >= 0; i-- {
+		c := contractions[i]
+		uf.rollback(c.time)
+		entering := in[c.node]
+		for _, a := range c.arcs {
+			in[uf.find(a%n)] = a
+		}
+		in[uf.find(entering%n)] = entering
+	}
+
+	in[root] = -1
+	return in, true
+}
@@ -0,0 +1,147 @@
+package msa
+
+import (
+	"github.com/gyuho/goraph"
+	"math"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// randomMatrix returns a random n×n weight matrix, with about the given fraction of absent arcs
+func randomMatrix(r *rand.Rand, n int, absent float64) [][]float64 {
+	scores := make([][]float64, n)
+	for h := range scores {
+		scores[h] = make([]float64, n)
+		for d := range scores[h] {
+			if r.Float64() < absent {
+				scores[h][d] = math.Inf(1)
+			} else {
+				scores[h][d] = float64(r.Intn(20) - 5)
+			}
+		}
+	}
+	return scores
+}
+
+// matrixAdjacency converts a weight matrix into an Adjacency whose IDs are the indexes
+func matrixAdjacency(scores [][]float64) Adjacency {
+	a := make(Adjacency)
+	for d := range scores {
+		a[strconv.Itoa(d)] = make(map[string]float64)
+	}
+	for h, row := range scores {
+		for d, weight := range row {
+			if !math.IsInf(weight, 0) {
+				a[strconv.Itoa(d)][strconv.Itoa(h)] = weight
+			}
+		}
+	}
+	return a
+}
+
+// checkHeads checks that heads is a spanning arborescence rooted at root, and returns its weight
+func checkHeads(t *testing.T, scores [][]float64, root int, heads []int) float64 {
+	var weight float64
+	for v := range heads {
+		if v == root {
+			if heads[v] != -1 {
+				t.Fatalf("The root has parent %d", heads[v])
+			}
+			continue
+		}
+		weight += scores[heads[v]][v]
+		u := v
+		for steps := 0; u != root; steps++ {
+			if steps == len(heads) {
+				t.Fatalf("Node %d doesn't lead back to the root: %v", v, heads)
+			}
+			u = heads[u]
+		}
+	}
+	return weight
+}
+
+// Test that the dense algorithm finds arborescences of the same weight as Solve
+func TestMSAMatrix(t *testing.T) {
+	r := rand.New(rand.NewSource(11))
+	for i := 0; i < 500; i++ {
+		n := 1 + r.Intn(25)
+		scores := randomMatrix(r, n, r.Float64())
+		root := r.Intn(n)
+		for _, obj := range []Objective{Minimize, Maximize} {
+			expected, expectedErr := SolveGraph(matrixAdjacency(scores), goraph.StringID(strconv.Itoa(root)), WithObjective(obj), WithAlgorithm(Tarjan))
+			heads, err := MSAMatrix(scores, root, WithObjective(obj))
+			if expectedErr != nil {
+				if _, ok := err.(*InfeasibleError); !ok {
+					t.Fatalf("Expected an *InfeasibleError, got %v", err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if weight := checkHeads(t, scores, root, heads); weight != expected.Weight {
+				t.Fatalf("%v: expected weight %v, got %v for %v", obj, expected.Weight, weight, scores)
+			}
+
+			flat := make([]float64, 0, n*n)
+			for _, row := range scores {
+				flat = append(flat, row...)
+			}
+			flatHeads, err := MSAMatrixFlat(flat, n, root, WithObjective(obj))
+			if err != nil || !reflect.DeepEqual(flatHeads, heads) {
+				t.Fatalf("MSAMatrixFlat returned %v (error: %v), MSAMatrix %v", flatHeads, err, heads)
+			}
+		}
+	}
+}
+
+func TestMSAMatrix_Example(t *testing.T) {
+	inf := math.Inf(1)
+	scores := [][]float64{
+		{inf, 5, 1, inf},
+		{inf, inf, 1, 2},
+		{inf, 1, inf, 3},
+		{inf, inf, inf, -1},
+	}
+	heads, err := MSAMatrix(scores, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int{-1, 2, 0, 1}; !reflect.DeepEqual(heads, expected) {
+		t.Errorf("Expected heads %v, got %v", expected, heads)
+	}
+
+	scores[1][3], scores[2][3] = inf, inf
+	if _, err := MSAMatrix(scores, 0); err == nil {
+		t.Error("Expected an error for an infeasible matrix")
+	} else if ie, ok := err.(*InfeasibleError); !ok || len(ie.Unreachable) != 1 || ie.Unreachable[0].String() != "3" {
+		t.Errorf("Expected an *InfeasibleError listing node 3, got %v", err)
+	}
+
+	if _, err := MSAMatrix([][]float64{{0, 1}, {1}}, 0); err == nil {
+		t.Error("Expected an error for a matrix that isn't square")
+	}
+	if _, err := MSAMatrixFlat([]float64{0, 1, 1}, 2, 0); err == nil {
+		t.Error("Expected an error for a flat matrix of the wrong size")
+	}
+	if _, err := MSAMatrix([][]float64{{0, math.NaN()}, {1, 0}}, 0); err == nil {
+		t.Error("Expected an error for a NaN weight")
+	}
+}
+
+func benchmarkMSAMatrix(b *testing.B, n int) {
+	scores := randomMatrix(rand.New(rand.NewSource(42)), n, 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := MSAMatrix(scores, 0, WithObjective(Maximize)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMSAMatrix_50(b *testing.B)   { benchmarkMSAMatrix(b, 50) }
+func BenchmarkMSAMatrix_500(b *testing.B)  { benchmarkMSAMatrix(b, 500) }
+func BenchmarkMSAMatrix_2000(b *testing.B) { benchmarkMSAMatrix(b, 2000) }
@@ -1,12 +1,48 @@
 package msa
 
 import (
+	"fmt"
 	"github.com/gyuho/goraph"
+	"math"
 	"os"
 	"strconv"
 	"testing"
 )
 
+// checkMSA checks that g has been reduced to a spanning arborescence rooted at root, of the given weight
+func checkMSA(t *testing.T, g goraph.Graph, root goraph.ID, weight float64) {
+	edges, err := GetEdges(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edges) != g.GetNodeCount()-1 {
+		t.Errorf("Expected %d edges, got %d", g.GetNodeCount()-1, len(edges))
+	}
+
+	// Every node but the root must have a single parent, and lead back to the root
+	for id := range g.GetNodes() {
+		for steps := 0; id.String() != root.String(); steps++ {
+			sources, err := g.GetSources(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sources) != 1 || steps == g.GetNodeCount() {
+				t.Fatalf("Node %s isn't attached to the root", id)
+			}
+			for id = range sources {
+			}
+		}
+	}
+
+	total, err := TotalWeight(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != weight {
+		t.Errorf("Expected a total weight of %v, got %v", weight, total)
+	}
+}
+
 func TestGraph_MSA_17_D(t *testing.T) {
 
 	// Get graph
@@ -28,10 +64,11 @@ func TestGraph_MSA_17_D(t *testing.T) {
 
 	// Process graph
 	feasible, err := MSA(g, goraph.StringID("D"))
-	t.Logf("For MSA test with root %s:\n\tInput: \n%s\n\tFeasible: %v\n\tValid: \n%s\n\tGot: \n%s\n", "D", startgstr, feasible, "NONE", g.String())
+	t.Logf("For MSA test with root %s:\n\tInput: \n%s\n\tFeasible: %v\n\tGot: \n%s\n", "D", startgstr, feasible, g.String())
 	if err != nil {
 		t.Errorf("Error while calculating MSA (%v)", err)
 	}
+	checkMSA(t, g, goraph.StringID("D"), 15)
 }
 
 func TestGraph_MSA_17_C(t *testing.T) {
@@ -56,10 +93,11 @@ func TestGraph_MSA_17_C(t *testing.T) {
 
 	// Process graph
 	feasible, err := MSA(g, goraph.StringID("C"))
-	t.Logf("For MSA test with root %s:\n\tInput: \n%s\n\tFeasible: %v\n\tValid: \n%s\n\tGot: \n%s\n", "C", startgstr, feasible, "NONE", g.String())
+	t.Logf("For MSA test with root %s:\n\tInput: \n%s\n\tFeasible: %v\n\tGot: \n%s\n", "C", startgstr, feasible, g.String())
 	if err != nil {
 		t.Errorf("Error while calculating MSA (%v)", err)
 	}
+	checkMSA(t, g, goraph.StringID("C"), 23)
 }
 
 func TestGraph_MSAAllRoots_17(t *testing.T) {
@@ -134,3 +172,130 @@ func TestGraph_MSAAllRoots_All(t *testing.T) {
 		t.Logf("DONE, feasability: %v, root: %s", feasible, rootID)
 	}
 }
+
+// Test that the naive and efficient algorithms agree on every graph and every root
+func TestSolve_NaiveMatchesTarjan(t *testing.T) {
+	for i := 0; i <= 17; i++ {
+		graphID := fmt.Sprintf("graph_%02d", i)
+		g := loadGraph(t, graphID)
+		for root := range g.GetNodes() {
+			naive, naiveErr := Solve(g, root, WithAlgorithm(Naive))
+			tarjan, tarjanErr := Solve(g, root, WithAlgorithm(Tarjan))
+			if (naiveErr == nil) != (tarjanErr == nil) {
+				t.Errorf("%s rooted at %s: naive returned error %v, tarjan returned error %v", graphID, root, naiveErr, tarjanErr)
+				continue
+			}
+			if naiveErr != nil {
+				continue
+			}
+			if naive.Weight != tarjan.Weight {
+				t.Errorf("%s rooted at %s: naive found weight %v, tarjan found %v", graphID, root, naive.Weight, tarjan.Weight)
+			}
+			if len(naive.Parent) != g.GetNodeCount()-1 {
+				t.Errorf("%s rooted at %s: naive found %d parents, expected %d", graphID, root, len(naive.Parent), g.GetNodeCount()-1)
+			}
+		}
+	}
+}
+
+// newTestGraph builds a graph out of the given edges, written as "source target"
+func newTestGraph(t *testing.T, weights map[string]float64) goraph.Graph {
+	g := goraph.NewGraph()
+	for edge, weight := range weights {
+		var source, target string
+		if _, err := fmt.Sscan(edge, &source, &target); err != nil {
+			t.Fatal(err)
+		}
+		g.AddNode(goraph.NewNode(source))
+		g.AddNode(goraph.NewNode(target))
+		if err := g.ReplaceEdge(goraph.StringID(source), goraph.StringID(target), weight); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return g
+}
+
+func TestMSA_ZeroAndNegativeWeights(t *testing.T) {
+	tests := []struct {
+		weights map[string]float64
+		weight  float64
+	}{
+		// The zero-weight edge must be preferred
+		{map[string]float64{"A B": 0, "C B": 5, "A C": 1}, 1},
+		// Log-probabilities
+		{map[string]float64{"A B": -0.1, "A C": -2.3, "B C": -0.5, "C B": -1.2}, -3.5},
+		// A cycle of negative edges, entered through a zero-weight edge
+		{map[string]float64{"A B": 3, "B C": -2, "C D": -4, "D B": -1, "A D": 0}, -3},
+		// Only zeros
+		{map[string]float64{"A B": 0, "B C": 0, "C B": 0, "A C": 0}, 0},
+	}
+
+	for i, test := range tests {
+		for _, alg := range []Algorithm{Naive, Tarjan} {
+			g := newTestGraph(t, test.weights)
+			arb, err := Solve(g, goraph.StringID("A"), WithAlgorithm(alg))
+			if err != nil {
+				t.Errorf("Test %d (%v): unexpected error: %v", i, alg, err)
+				continue
+			}
+			if math.Abs(arb.Weight-test.weight) > 1e-9 {
+				t.Errorf("Test %d (%v): expected weight %v, got %v", i, alg, test.weight, arb.Weight)
+			}
+		}
+
+		g := newTestGraph(t, test.weights)
+		if _, err := MSA(g, goraph.StringID("A")); err != nil {
+			t.Fatalf("Test %d: unexpected error: %v", i, err)
+		}
+		checkMSA(t, g, goraph.StringID("A"), test.weight)
+	}
+}
+
+func TestMSA_RejectsNaN(t *testing.T) {
+	for _, weight := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		for _, alg := range []Algorithm{Naive, Tarjan} {
+			g := newTestGraph(t, map[string]float64{"A B": 1, "B C": weight})
+			if _, err := Solve(g, goraph.StringID("A"), WithAlgorithm(alg)); err == nil {
+				t.Errorf("Expected an error for weight %v (%v)", weight, alg)
+			}
+		}
+	}
+}
+
+func TestMSA_Infeasible(t *testing.T) {
+	expected := []string{"B", "C", "D", "E"}
+
+	check := func(err error) {
+		ierr, ok := err.(*InfeasibleError)
+		if !ok {
+			t.Fatalf("Expected an *InfeasibleError, got %v", err)
+		}
+		if ierr.Root.String() != "A" {
+			t.Errorf("Expected root A, got %s", ierr.Root)
+		}
+		if len(ierr.Unreachable) != len(expected) {
+			t.Fatalf("Expected unreachable nodes %v, got %v", expected, ierr.Unreachable)
+		}
+		for i, id := range ierr.Unreachable {
+			if id.String() != expected[i] {
+				t.Fatalf("Expected unreachable nodes %v, got %v", expected, ierr.Unreachable)
+			}
+		}
+	}
+
+	g := loadGraph(t, "graph_05")
+	before := edgeStrings(t, g)
+	feasible, err := MSA(g, goraph.StringID("A"))
+	if feasible {
+		t.Error("MSA reported graph_05 as feasible from A")
+	}
+	check(err)
+	if after := edgeStrings(t, g); len(after) != len(before) {
+		t.Errorf("MSA modified an infeasible graph: had %v, now %v", before, after)
+	}
+
+	for _, alg := range []Algorithm{Naive, Tarjan} {
+		_, err := Solve(g, goraph.StringID("A"), WithAlgorithm(alg))
+		check(err)
+	}
+}
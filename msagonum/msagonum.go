@@ -0,0 +1,94 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+Package msagonum adapts gonum graphs to msa.Graph, so that they can be solved by msa.SolveGraph without being copied into a goraph.Graph
+
+Node IDs are the decimal representation of gonum's, see ID and NodeID.
+
+Like gonum, it requires Go 1.18 or later, and is left out of the build by older versions.
+*/
+package msagonum
+
+import (
+	"fmt"
+	"github.com/aabizri/msa"
+	"github.com/gyuho/goraph"
+	"gonum.org/v1/gonum/graph"
+	"strconv"
+)
+
+// adapter adapts a gonum graph to msa.Graph
+type adapter struct {
+	g graph.WeightedDirected
+}
+
+// New adapts g to msa.Graph
+func New(g graph.WeightedDirected) msa.Graph {
+	return adapter{g}
+}
+
+// Nodes returns the IDs of every node
+func (a adapter) Nodes() []goraph.ID {
+	var ids []goraph.ID
+	nodes := a.g.Nodes()
+	for nodes.Next() {
+		ids = append(ids, ID(nodes.Node().ID()))
+	}
+	return ids
+}
+
+// Incoming returns the edges going into the node with the given ID
+func (a adapter) Incoming(id goraph.ID) ([]goraph.Edge, error) {
+	vid, err := NodeID(id)
+	if err != nil {
+		return nil, fmt.Errorf("Incoming: %v", err)
+	}
+	if a.g.Node(vid) == nil {
+		return nil, fmt.Errorf("Incoming: node %s isn't in the graph", id.String())
+	}
+
+	target := goraph.NewNode(id.String())
+	var edges []goraph.Edge
+	sources := a.g.To(vid)
+	for sources.Next() {
+		uid := sources.Node().ID()
+		e := a.g.WeightedEdge(uid, vid)
+		if e == nil {
+			return nil, fmt.Errorf("Incoming: no edge goes from %d to %d, though %d is listed as a source", uid, vid, uid)
+		}
+		edges = append(edges, goraph.NewEdge(goraph.NewNode(ID(uid).String()), target, e.Weight()))
+	}
+	return edges, nil
+}
+
+// ID returns the msa ID of the gonum node with the given ID
+func ID(id int64) goraph.ID {
+	return goraph.StringID(strconv.FormatInt(id, 10))
+}
+
+// NodeID returns the gonum ID of the node with the given msa ID
+func NodeID(id goraph.ID) (int64, error) {
+	n, err := strconv.ParseInt(id.String(), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("NodeID: %s isn't the ID of a gonum node: %v", id.String(), err)
+	}
+	return n, nil
+}
+
+// Parents maps the gonum ID of every non-root node of arb to the one of its parent
+func Parents(arb *msa.Arborescence) (map[int64]int64, error) {
+	parents := make(map[int64]int64, len(arb.Parent))
+	for _, e := range arb.Edges {
+		source, err := NodeID(e.Source().ID())
+		if err != nil {
+			return nil, fmt.Errorf("Parents: %v", err)
+		}
+		target, err := NodeID(e.Target().ID())
+		if err != nil {
+			return nil, fmt.Errorf("Parents: %v", err)
+		}
+		parents[target] = source
+	}
+	return parents, nil
+}
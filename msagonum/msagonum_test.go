@@ -0,0 +1,100 @@
+//go:build go1.18
+// +build go1.18
+
+package msagonum
+
+import (
+	"fmt"
+	"github.com/aabizri/msa"
+	"github.com/gyuho/goraph"
+	"gonum.org/v1/gonum/graph/simple"
+	"math"
+	"os"
+	"sort"
+	"testing"
+)
+
+// loadGraph loads the graph with the given ID from the testdata of package msa, along with its copy as a gonum graph
+// Nodes are numbered following the order of their IDs
+func loadGraph(t *testing.T, graphID string) (goraph.Graph, *simple.WeightedDirectedGraph, map[string]int64) {
+	f, err := os.Open("../testdata/graph.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	g, err := goraph.NewGraphFromJSON(f, graphID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for id := range g.GetNodes() {
+		names = append(names, id.String())
+	}
+	sort.Strings(names)
+	numbers := make(map[string]int64, len(names))
+	gg := simple.NewWeightedDirectedGraph(0, math.Inf(1))
+	for i, name := range names {
+		numbers[name] = int64(i)
+		gg.AddNode(simple.Node(i))
+	}
+
+	edges, err := msa.GetEdges(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range edges {
+		source, target := numbers[e.Source().ID().String()], numbers[e.Target().ID().String()]
+		if source == target {
+			continue // simple graphs don't allow self-loops
+		}
+		gg.SetWeightedEdge(gg.NewWeightedEdge(simple.Node(source), simple.Node(target), e.Weight()))
+	}
+	return g, gg, numbers
+}
+
+// Test that solving a gonum graph gives the same result as solving the goraph one
+func TestNew(t *testing.T) {
+	for i := 0; i <= 17; i++ {
+		graphID := fmt.Sprintf("graph_%02d", i)
+		g, gg, numbers := loadGraph(t, graphID)
+		for root := range g.GetNodes() {
+			expected, expectedErr := msa.Solve(g, root)
+			for _, alg := range []msa.Algorithm{msa.Naive, msa.Tarjan} {
+				arb, err := msa.SolveGraph(New(gg), ID(numbers[root.String()]), msa.WithAlgorithm(alg))
+				if (err == nil) != (expectedErr == nil) {
+					t.Errorf("%s rooted at %s: expected error %v, got %v", graphID, root, expectedErr, err)
+					continue
+				}
+				if err != nil {
+					continue
+				}
+				if arb.Weight != expected.Weight {
+					t.Errorf("%s rooted at %s with %v: expected weight %v, got %v", graphID, root, alg, expected.Weight, arb.Weight)
+				}
+			}
+		}
+	}
+}
+
+func TestParents(t *testing.T) {
+	g := simple.NewWeightedDirectedGraph(0, math.Inf(1))
+	for _, e := range []struct {
+		from, to int64
+		weight   float64
+	}{{0, 1, 5}, {0, 2, 1}, {2, 1, 1}, {1, 2, 1}} {
+		g.SetWeightedEdge(g.NewWeightedEdge(simple.Node(e.from), simple.Node(e.to), e.weight))
+	}
+
+	arb, err := msa.SolveGraph(New(g), ID(0), msa.WithAlgorithm(msa.Tarjan))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parents, err := Parents(arb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parents) != 2 || parents[2] != 0 || parents[1] != 2 {
+		t.Errorf("Expected parents map[1:2 2:0], got %v", parents)
+	}
+}
@@ -0,0 +1,381 @@
+package msaio
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/aabizri/msa"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// This file implements reading and writing dependency trees in the CoNLL-U format, and decoding them from arc scores
+// See https://universaldependencies.org/format.html
+
+// Token is a word of a CoNLL-U sentence
+type Token struct {
+	// ID is the index of the word in its sentence, starting at 1
+	ID int
+
+	Form   string
+	Lemma  string
+	UPOS   string
+	XPOS   string
+	Feats  string
+	Head   int // the ID of the head of the word, 0 for the root, -1 if unspecified
+	DepRel string
+	Deps   string
+	Misc   string
+}
+
+// Sentence is a CoNLL-U sentence
+type Sentence struct {
+	// Comments are the comment lines preceding the sentence, without their leading "#"
+	Comments []string
+
+	// Tokens are the words of the sentence, the i-th having ID i+1
+	Tokens []Token
+
+	// others are the lines of multiword tokens and empty nodes, which are written back as they were read
+	others []otherLine
+}
+
+// otherLine is a line of a multiword token or an empty node
+type otherLine struct {
+	after int // the number of words preceding it
+	text  string
+}
+
+// CoNLLUReader reads sentences in the CoNLL-U format
+type CoNLLUReader struct {
+	s    *bufio.Scanner
+	line int
+}
+
+// NewCoNLLUReader returns a CoNLLUReader reading from r
+func NewCoNLLUReader(r io.Reader) *CoNLLUReader {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1<<20)
+	return &CoNLLUReader{s: s}
+}
+
+// Read reads the next sentence, returning io.EOF when there are none left
+func (r *CoNLLUReader) Read() (*Sentence, error) {
+	var (
+		sentence Sentence
+		started  bool
+		heads    []int // the line of every word, to report invalid heads
+	)
+	for r.s.Scan() {
+		r.line++
+		line := r.s.Text()
+		if strings.TrimSpace(line) == "" {
+			if started {
+				break
+			}
+			continue
+		}
+		started = true
+		if strings.HasPrefix(line, "#") {
+			if len(sentence.Tokens) != 0 {
+				return nil, fmt.Errorf("CoNLLUReader.Read: line %d: comment inside a sentence", r.line)
+			}
+			sentence.Comments = append(sentence.Comments, line[1:])
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 10 {
+			return nil, fmt.Errorf("CoNLLUReader.Read: line %d: expected 10 tab-separated fields, got %d", r.line, len(fields))
+		}
+		if strings.ContainsAny(fields[0], "-.") {
+			sentence.others = append(sentence.others, otherLine{after: len(sentence.Tokens), text: line})
+			continue
+		}
+		tok, err := parseToken(fields)
+		if err != nil {
+			return nil, fmt.Errorf("CoNLLUReader.Read: line %d: %v", r.line, err)
+		}
+		if tok.ID != len(sentence.Tokens)+1 {
+			return nil, fmt.Errorf("CoNLLUReader.Read: line %d: expected word %d, got %d", r.line, len(sentence.Tokens)+1, tok.ID)
+		}
+		sentence.Tokens = append(sentence.Tokens, tok)
+		heads = append(heads, r.line)
+	}
+	if err := r.s.Err(); err != nil {
+		return nil, fmt.Errorf("CoNLLUReader.Read: line %d: %v", r.line+1, err)
+	}
+	if !started {
+		return nil, io.EOF
+	}
+	if len(sentence.Tokens) == 0 {
+		return nil, fmt.Errorf("CoNLLUReader.Read: line %d: sentence without words", r.line)
+	}
+	for i, tok := range sentence.Tokens {
+		if tok.Head > len(sentence.Tokens) {
+			return nil, fmt.Errorf("CoNLLUReader.Read: line %d: head %d isn't in the sentence of %d words", heads[i], tok.Head, len(sentence.Tokens))
+		}
+	}
+	return &sentence, nil
+}
+
+// parseToken parses the fields of a word line
+func parseToken(fields []string) (Token, error) {
+	id, err := strconv.Atoi(fields[0])
+	if err != nil || id < 1 {
+		return Token{}, fmt.Errorf("invalid ID %q", fields[0])
+	}
+	head := -1
+	if fields[6] != "_" {
+		head, err = strconv.Atoi(fields[6])
+		if err != nil || head < 0 {
+			return Token{}, fmt.Errorf("invalid head %q", fields[6])
+		}
+	}
+	return Token{
+		ID:     id,
+		Form:   fields[1],
+		Lemma:  fields[2],
+		UPOS:   fields[3],
+		XPOS:   fields[4],
+		Feats:  fields[5],
+		Head:   head,
+		DepRel: fields[7],
+		Deps:   fields[8],
+		Misc:   fields[9],
+	}, nil
+}
+
+// WriteCoNLLU writes a sentence in the CoNLL-U format, followed by a blank line
+func WriteCoNLLU(w io.Writer, s *Sentence) error {
+	bw := bufio.NewWriter(w)
+	for _, comment := range s.Comments {
+		fmt.Fprintf(bw, "#%s\n", comment)
+	}
+	others := s.others
+	for i := 0; i <= len(s.Tokens); i++ {
+		for len(others) != 0 && others[0].after == i {
+			fmt.Fprintln(bw, others[0].text)
+			others = others[1:]
+		}
+		if i == len(s.Tokens) {
+			break
+		}
+		tok := s.Tokens[i]
+		head := "_"
+		if tok.Head >= 0 {
+			head = strconv.Itoa(tok.Head)
+		}
+		fields := []string{strconv.Itoa(tok.ID), tok.Form, tok.Lemma, tok.UPOS, tok.XPOS, tok.Feats, head, tok.DepRel, tok.Deps, tok.Misc}
+		for j, field := range fields {
+			if field == "" {
+				fields[j] = "_"
+			}
+		}
+		fmt.Fprintln(bw, strings.Join(fields, "\t"))
+	}
+	fmt.Fprintln(bw)
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("WriteCoNLLU: %v", err)
+	}
+	return nil
+}
+
+// Scorer scores the arcs between the words of a sentence, as a dependency parser does
+type Scorer interface {
+	// Score returns the (n+1)×(n+1) matrix of scores of the arcs of a sentence of n words, scores[h][d] being that of word h heading word d, 0 being the root.
+	// Higher scores are better, and infinite ones mark forbidden arcs.
+	Score(s *Sentence) ([][]float64, error)
+}
+
+// ScorerFunc is a function used as a Scorer
+type ScorerFunc func(s *Sentence) ([][]float64, error)
+
+// Score calls f(s)
+func (f ScorerFunc) Score(s *Sentence) ([][]float64, error) {
+	return f(s)
+}
+
+// Decode sets the head of every word of s to that of the maximum spanning arborescence of its arcs scored by scorer, rooted at 0
+// Several words may depend on the root. The options are passed to msa.MSAMatrix, the objective always being msa.Maximize.
+func Decode(s *Sentence, scorer Scorer, opts ...msa.Option) error {
+	scores, err := scoreSentence(s, scorer)
+	if err != nil {
+		return fmt.Errorf("Decode: %v", err)
+	}
+	heads, err := msa.MSAMatrix(scores, 0, append(opts[:len(opts):len(opts)], msa.WithObjective(msa.Maximize))...)
+	if err != nil {
+		return fmt.Errorf("Decode: %v", err)
+	}
+	setHeads(s, heads)
+	return nil
+}
+
+// LabeledScorer scores the arcs between the words of a sentence for every dependency relation
+type LabeledScorer interface {
+	// ScoreLabeled returns, for every dependency relation, the matrix of scores of the arcs of s labelled with it, as Scorer.Score does
+	ScoreLabeled(s *Sentence) (map[string][][]float64, error)
+}
+
+// DecodeLabeled is like Decode, but also sets the dependency relation of every word
+// The best relation of every arc is selected before decoding, the first in lexicographic order on ties.
+// Infinite scores mark the relations an arc can't have, the arc being absent if it can't have any.
+func DecodeLabeled(s *Sentence, scorer LabeledScorer, opts ...msa.Option) error {
+	labeled, err := scorer.ScoreLabeled(s)
+	if err != nil {
+		return fmt.Errorf("DecodeLabeled: error while scoring: %v", err)
+	}
+	if len(labeled) == 0 {
+		return fmt.Errorf("DecodeLabeled: no dependency relation was scored")
+	}
+	relations := make([]string, 0, len(labeled))
+	for relation := range labeled {
+		relations = append(relations, relation)
+	}
+	sort.Strings(relations)
+
+	// Select the best relation of every arc, arcs whose every score is infinite being absent
+	n := len(s.Tokens) + 1
+	scores := make([][]float64, n)
+	best := make([][]string, n)
+	for h := range scores {
+		scores[h] = make([]float64, n)
+		best[h] = make([]string, n)
+		for d := range scores[h] {
+			scores[h][d] = math.Inf(-1)
+		}
+	}
+	for _, relation := range relations {
+		m := labeled[relation]
+		if len(m) != n {
+			return fmt.Errorf("DecodeLabeled: got %d rows of scores for relation %s, expected %d", len(m), relation, n)
+		}
+		for h, row := range m {
+			if len(row) != n {
+				return fmt.Errorf("DecodeLabeled: row %d of relation %s has %d scores, expected %d", h, relation, len(row), n)
+			}
+			for d, score := range row {
+				if !math.IsInf(score, 0) && score > scores[h][d] {
+					scores[h][d] = score
+					best[h][d] = relation
+				}
+			}
+		}
+	}
+
+	heads, err := msa.MSAMatrix(scores, 0, append(opts[:len(opts):len(opts)], msa.WithObjective(msa.Maximize))...)
+	if err != nil {
+		return fmt.Errorf("DecodeLabeled: %v", err)
+	}
+	setHeads(s, heads)
+	for i := range s.Tokens {
+		s.Tokens[i].DepRel = best[heads[i+1]][i+1]
+	}
+	return nil
+}
+
+// DecodeAll decodes every sentence like Decode, solving them concurrently with msa.SolveBatch
+// Sentences are scored one after the other, so that scorer needn't be safe for concurrent use.
+// It returns the error encountered for every sentence, nil if it was decoded.
+func DecodeAll(sentences []*Sentence, scorer Scorer, opts ...msa.Option) []error {
+	errs := make([]error, len(sentences))
+	problems := make([]msa.Problem, len(sentences))
+	for i, s := range sentences {
+		scores, err := scoreSentence(s, scorer)
+		if err != nil {
+			errs[i] = fmt.Errorf("DecodeAll: sentence %d: %v", i, err)
+			continue
+		}
+		problems[i] = msa.Problem{Matrix: scores}
+	}
+	for _, r := range msa.SolveBatch(problems, append(opts[:len(opts):len(opts)], msa.WithObjective(msa.Maximize))...) {
+		if errs[r.Index] != nil {
+			continue
+		}
+		if r.Err != nil {
+			errs[r.Index] = fmt.Errorf("DecodeAll: sentence %d: %v", r.Index, r.Err)
+			continue
+		}
+		setHeads(sentences[r.Index], r.Heads)
+	}
+	return errs
+}
+
+// scoreSentence scores the arcs of s, checking the size of the matrix
+func scoreSentence(s *Sentence, scorer Scorer) ([][]float64, error) {
+	scores, err := scorer.Score(s)
+	if err != nil {
+		return nil, fmt.Errorf("error while scoring: %v", err)
+	}
+	if len(scores) != len(s.Tokens)+1 {
+		return nil, fmt.Errorf("got %d rows of scores for %d words, expected %d", len(scores), len(s.Tokens), len(s.Tokens)+1)
+	}
+	return scores, nil
+}
+
+// setHeads sets the heads of the words of s from those of the nodes of its arborescence
+func setHeads(s *Sentence, heads []int) {
+	for i := range s.Tokens {
+		s.Tokens[i].Head = heads[i+1]
+	}
+}
+
+// Evaluation counts the words whose head and label are correct, from which attachment scores are computed
+type Evaluation struct {
+	Words    int // the number of words evaluated
+	Attached int // the number of words with the right head
+	Labeled  int // the number of words with the right head and dependency relation
+}
+
+// Add evaluates the words of predicted against those of gold
+func (e *Evaluation) Add(predicted, gold *Sentence) error {
+	if len(predicted.Tokens) != len(gold.Tokens) {
+		return fmt.Errorf("Add: %d words were predicted, gold has %d", len(predicted.Tokens), len(gold.Tokens))
+	}
+	for i, tok := range predicted.Tokens {
+		g := gold.Tokens[i]
+		if tok.Form != g.Form {
+			return fmt.Errorf("Add: word %d is %q, %q in gold", tok.ID, tok.Form, g.Form)
+		}
+		e.Words++
+		if tok.Head == g.Head {
+			e.Attached++
+			if tok.DepRel == g.DepRel {
+				e.Labeled++
+			}
+		}
+	}
+	return nil
+}
+
+// UAS returns the unlabeled attachment score, the ratio of words with the right head
+func (e Evaluation) UAS() float64 {
+	if e.Words == 0 {
+		return 0
+	}
+	return float64(e.Attached) / float64(e.Words)
+}
+
+// LAS returns the labeled attachment score, the ratio of words with the right head and dependency relation
+func (e Evaluation) LAS() float64 {
+	if e.Words == 0 {
+		return 0
+	}
+	return float64(e.Labeled) / float64(e.Words)
+}
+
+// Evaluate evaluates predicted sentences against their gold counterparts
+func Evaluate(predicted, gold []*Sentence) (Evaluation, error) {
+	var e Evaluation
+	if len(predicted) != len(gold) {
+		return e, fmt.Errorf("Evaluate: %d sentences were predicted, gold has %d", len(predicted), len(gold))
+	}
+	for i := range predicted {
+		if err := e.Add(predicted[i], gold[i]); err != nil {
+			return e, fmt.Errorf("Evaluate: sentence %d: %v", i, err)
+		}
+	}
+	return e, nil
+}
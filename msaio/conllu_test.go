@@ -0,0 +1,312 @@
+package msaio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+const conlluExample = `# sent_id = 1
+# text = They buy and sell books.
+1	They	they	PRON	PRP	Case=Nom|Number=Plur	2	nsubj	2:nsubj|4:nsubj	_
+2	buy	buy	VERB	VBP	Number=Plur|Person=3|Tense=Pres	0	root	0:root	_
+3	and	and	CCONJ	CC	_	4	cc	4:cc	_
+4	sell	sell	VERB	VBP	Number=Plur|Person=3|Tense=Pres	2	conj	0:root|2:conj	_
+5	books	book	NOUN	NNS	Number=Plur	2	obj	2:obj|4:obj	SpaceAfter=No
+6	.	.	PUNCT	.	_	2	punct	2:punct	_
+
+# sent_id = 2
+# text = vámonos al mar
+1-2	vámonos	_	_	_	_	_	_	_	_
+1	vamos	ir	VERB	_	_	0	root	_	_
+2	nos	nosotros	PRON	_	_	1	obj	_	_
+3-4	al	_	_	_	_	_	_	_	_
+3	a	a	ADP	_	_	5	case	_	_
+4	el	el	DET	_	_	5	det	_	_
+4.1	ido	ir	VERB	_	_	_	_	1:conj	_
+5	mar	mar	NOUN	_	_	1	obl	_	_
+
+`
+
+// readAll reads every sentence of input
+func readAll(t *testing.T, input string) []*Sentence {
+	r := NewCoNLLUReader(strings.NewReader(input))
+	var sentences []*Sentence
+	for {
+		s, err := r.Read()
+		if err == io.EOF {
+			return sentences
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		sentences = append(sentences, s)
+	}
+}
+
+// copySentence returns a copy of s whose words can be modified
+func copySentence(s *Sentence) *Sentence {
+	c := *s
+	c.Tokens = append([]Token(nil), s.Tokens...)
+	return &c
+}
+
+// goldScorer scores the gold arcs of a sentence 1, the others 0, and arcs going to the root or from a word to itself -Inf
+var goldScorer = ScorerFunc(func(s *Sentence) ([][]float64, error) {
+	n := len(s.Tokens) + 1
+	scores := make([][]float64, n)
+	for h := range scores {
+		scores[h] = make([]float64, n)
+		scores[h][0] = math.Inf(-1)
+		scores[h][h] = math.Inf(-1)
+	}
+	for _, tok := range s.Tokens {
+		scores[tok.Head][tok.ID] = 1
+	}
+	return scores, nil
+})
+
+func TestCoNLLU_RoundTrip(t *testing.T) {
+	sentences := readAll(t, conlluExample)
+	if len(sentences) != 2 {
+		t.Fatalf("read %d sentences, expected 2", len(sentences))
+	}
+	if n := len(sentences[1].Tokens); n != 5 {
+		t.Errorf("read %d words in the second sentence, expected 5", n)
+	}
+	if s := sentences[0]; s.Comments[1] != " text = They buy and sell books." || s.Tokens[4].Head != 2 || s.Tokens[4].Misc != "SpaceAfter=No" {
+		t.Errorf("misread the first sentence: %+v", s)
+	}
+
+	var buf bytes.Buffer
+	for _, s := range sentences {
+		if err := WriteCoNLLU(&buf, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if buf.String() != conlluExample {
+		t.Errorf("got:\n%s\nexpected:\n%s", buf.String(), conlluExample)
+	}
+}
+
+func TestCoNLLUReader_Errors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"1\ta\t_\t_\t_\t_\t0\troot\t_\n", "line 1: expected 10 tab-separated fields, got 9"},
+		{"# c\n1\ta\t_\t_\t_\t_\t0\troot\t_\t_\n3\tb\t_\t_\t_\t_\t1\tdep\t_\t_\n", "line 3: expected word 2, got 3"},
+		{"1\ta\t_\t_\t_\t_\tx\troot\t_\t_\n", `line 1: invalid head "x"`},
+		{"\n\n1\ta\t_\t_\t_\t_\t0\troot\t_\t_\n2\tb\t_\t_\t_\t_\t7\tdep\t_\t_\n", "line 4: head 7 isn't in the sentence of 2 words"},
+		{"1\ta\t_\t_\t_\t_\t0\troot\t_\t_\n# c\n", "line 2: comment inside a sentence"},
+		{"# only a comment\n", "sentence without words"},
+	}
+	for _, test := range tests {
+		_, err := NewCoNLLUReader(strings.NewReader(test.input)).Read()
+		if err == nil {
+			t.Errorf("%q: expected an error", test.input)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%q: got error %q, expected it to contain %q", test.input, err, test.err)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	gold := readAll(t, conlluExample)
+	var predicted []*Sentence
+	for _, s := range gold {
+		p := copySentence(s)
+		if err := Decode(p, goldScorer); err != nil {
+			t.Fatal(err)
+		}
+		predicted = append(predicted, p)
+	}
+	e, err := Evaluate(predicted, gold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Words != 11 || e.UAS() != 1 || e.LAS() != 1 {
+		t.Errorf("decoding with the gold scores gives %+v", e)
+	}
+
+	// A scorer preferring the previous word as head gives a chain
+	chain := ScorerFunc(func(s *Sentence) ([][]float64, error) {
+		scores, _ := goldScorer(s)
+		for d := 1; d < len(scores); d++ {
+			scores[d-1][d] = 2
+		}
+		return scores, nil
+	})
+	p := copySentence(gold[0])
+	if err := Decode(p, chain); err != nil {
+		t.Fatal(err)
+	}
+	for _, tok := range p.Tokens {
+		if tok.Head != tok.ID-1 {
+			t.Errorf("word %d has head %d, expected %d", tok.ID, tok.Head, tok.ID-1)
+		}
+	}
+	var buf bytes.Buffer
+	if err := WriteCoNLLU(&buf, p); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "5\tbooks\tbook\tNOUN\tNNS\tNumber=Plur\t4\tobj\t") {
+		t.Errorf("the head column isn't written back:\n%s", buf.String())
+	}
+
+	bad := ScorerFunc(func(s *Sentence) ([][]float64, error) {
+		return [][]float64{{0}}, nil
+	})
+	if err := Decode(copySentence(gold[0]), bad); err == nil {
+		t.Errorf("expected an error for a matrix of the wrong size")
+	}
+}
+
+func TestDecodeAll(t *testing.T) {
+	gold := readAll(t, conlluExample)
+	var sentences []*Sentence
+	for i := 0; i < 50; i++ {
+		sentences = append(sentences, copySentence(gold[i%len(gold)]))
+	}
+	failing := ScorerFunc(func(s *Sentence) ([][]float64, error) {
+		if s == sentences[7] {
+			return nil, fmt.Errorf("failing")
+		}
+		return goldScorer(s)
+	})
+	errs := DecodeAll(sentences, failing)
+	for i, err := range errs {
+		if (err != nil) != (i == 7) {
+			t.Errorf("sentence %d: unexpected error %v", i, err)
+		}
+	}
+	for i, s := range sentences {
+		if i == 7 {
+			continue
+		}
+		var e Evaluation
+		if err := e.Add(s, gold[i%len(gold)]); err != nil {
+			t.Fatal(err)
+		}
+		if e.UAS() != 1 {
+			t.Errorf("sentence %d: UAS is %v", i, e.UAS())
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	gold := readAll(t, conlluExample)
+	predicted := []*Sentence{copySentence(gold[0]), copySentence(gold[1])}
+	predicted[0].Tokens[0].Head = 4       // wrong head
+	predicted[0].Tokens[2].DepRel = "dep" // wrong label
+	e, err := Evaluate(predicted, gold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Words != 11 || e.Attached != 10 || e.Labeled != 9 {
+		t.Errorf("got %+v", e)
+	}
+	if math.Abs(e.UAS()-10.0/11) > 1e-12 || math.Abs(e.LAS()-9.0/11) > 1e-12 {
+		t.Errorf("got UAS %v and LAS %v", e.UAS(), e.LAS())
+	}
+
+	if _, err := Evaluate(predicted[:1], gold); err == nil {
+		t.Errorf("expected an error for a different number of sentences")
+	}
+	predicted[1].Tokens[0].Form = "other"
+	if _, err := Evaluate(predicted, gold); err == nil {
+		t.Errorf("expected an error for different words")
+	}
+}
+
+// goldLabeledScorer scores the gold relation of every gold arc 1, and any other relation of it 0.5
+type goldLabeledScorer []string
+
+func (relations goldLabeledScorer) ScoreLabeled(s *Sentence) (map[string][][]float64, error) {
+	labeled := make(map[string][][]float64, len(relations))
+	for _, relation := range relations {
+		scores, _ := goldScorer(s)
+		for _, tok := range s.Tokens {
+			if relation != tok.DepRel {
+				scores[tok.Head][tok.ID] = 0.5
+			}
+		}
+		labeled[relation] = scores
+	}
+	return labeled, nil
+}
+
+func TestDecodeLabeled(t *testing.T) {
+	gold := readAll(t, conlluExample)
+	scorer := goldLabeledScorer{"case", "cc", "conj", "det", "nsubj", "obj", "obl", "punct", "root"}
+	var predicted []*Sentence
+	for _, s := range gold {
+		p := copySentence(s)
+		for i := range p.Tokens {
+			p.Tokens[i].Head, p.Tokens[i].DepRel = -1, ""
+		}
+		// The scorer reads the gold tree
+		labeled, err := scorer.ScoreLabeled(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fixed := labeledScorerFunc(func(*Sentence) (map[string][][]float64, error) { return labeled, nil })
+		if err := DecodeLabeled(p, fixed); err != nil {
+			t.Fatal(err)
+		}
+		predicted = append(predicted, p)
+	}
+	e, err := Evaluate(predicted, gold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Words != 11 || e.UAS() != 1 || e.LAS() != 1 {
+		t.Errorf("decoding with the gold scores gives %+v", e)
+	}
+
+	// On ties, the first relation is selected
+	p := copySentence(gold[0])
+	tied := labeledScorerFunc(func(s *Sentence) (map[string][][]float64, error) {
+		scores, _ := goldScorer(s)
+		return map[string][][]float64{"b": scores, "a": scores}, nil
+	})
+	if err := DecodeLabeled(p, tied); err != nil {
+		t.Fatal(err)
+	}
+	for _, tok := range p.Tokens {
+		if tok.DepRel != "a" {
+			t.Errorf("word %d has relation %s, expected a", tok.ID, tok.DepRel)
+		}
+	}
+
+	// Infinite scores rule a relation out, rather than making it the best
+	p = copySentence(gold[0])
+	ruledOut := labeledScorerFunc(func(s *Sentence) (map[string][][]float64, error) {
+		scores, _ := goldScorer(s)
+		infinite, _ := goldScorer(s)
+		for _, tok := range s.Tokens {
+			infinite[tok.Head][tok.ID] = math.Inf(1)
+		}
+		return map[string][][]float64{"a": infinite, "b": scores}, nil
+	})
+	if err := DecodeLabeled(p, ruledOut); err != nil {
+		t.Fatal(err)
+	}
+	for i, tok := range p.Tokens {
+		if tok.DepRel != "b" || tok.Head != gold[0].Tokens[i].Head {
+			t.Errorf("word %d has head %d and relation %s, expected %d and b", tok.ID, tok.Head, tok.DepRel, gold[0].Tokens[i].Head)
+		}
+	}
+}
+
+// labeledScorerFunc is a function used as a LabeledScorer
+type labeledScorerFunc func(s *Sentence) (map[string][][]float64, error)
+
+func (f labeledScorerFunc) ScoreLabeled(s *Sentence) (map[string][][]float64, error) {
+	return f(s)
+}
@@ -0,0 +1,622 @@
+/*
+Package msaio reads and writes graphs for package msa, and the arborescences it finds, in common file formats: Graphviz DOT, delimited edge lists such as CSV, and CoNLL-U for dependency parsing
+*/
+package msaio
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/aabizri/msa"
+	"github.com/gyuho/goraph"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// This file implements the subset of the DOT language of Graphviz needed to describe weighted digraphs
+// See https://graphviz.org/doc/info/lang.html
+
+// dotToken is a token of the DOT language
+type dotToken struct {
+	text   string
+	quoted bool // whether it was a quoted string, which is always an ID
+	line   int
+}
+
+// dotLexer splits DOT input into tokens
+type dotLexer struct {
+	r    *bufio.Reader
+	line int
+}
+
+// next returns the next token, io.EOF at the end of the input
+func (l *dotLexer) next() (dotToken, error) {
+	for {
+		c, _, err := l.r.ReadRune()
+		if err != nil {
+			return dotToken{}, err
+		}
+		switch {
+		case c == '\n':
+			l.line++
+		case unicode.IsSpace(c):
+		case c == '#':
+			if err := l.skipLine(); err != nil {
+				return dotToken{}, err
+			}
+		case c == '/':
+			d, _, err := l.r.ReadRune()
+			if err != nil {
+				return dotToken{}, fmt.Errorf("line %d: unexpected end of input after /", l.line)
+			}
+			switch d {
+			case '/':
+				if err := l.skipLine(); err != nil {
+					return dotToken{}, err
+				}
+			case '*':
+				if err := l.skipComment(); err != nil {
+					return dotToken{}, err
+				}
+			default:
+				return dotToken{}, fmt.Errorf("line %d: unexpected character / ", l.line)
+			}
+		case strings.ContainsRune("{}[];,=:", c):
+			return dotToken{text: string(c), line: l.line}, nil
+		case c == '-':
+			d, _, err := l.r.ReadRune()
+			if err != nil {
+				return dotToken{}, fmt.Errorf("line %d: unexpected end of input after -", l.line)
+			}
+			if d == '>' || d == '-' {
+				return dotToken{text: string([]rune{c, d}), line: l.line}, nil
+			}
+			l.r.UnreadRune()
+			return l.word(c)
+		case c == '"':
+			return l.quoted()
+		case c == '<':
+			return dotToken{}, fmt.Errorf("line %d: HTML strings aren't supported", l.line)
+		default:
+			return l.word(c)
+		}
+	}
+}
+
+// skipLine skips the rest of the line
+func (l *dotLexer) skipLine() error {
+	_, err := l.r.ReadString('\n')
+	l.line++
+	return err
+}
+
+// skipComment skips a /* */ comment
+func (l *dotLexer) skipComment() error {
+	var previous rune
+	for {
+		c, _, err := l.r.ReadRune()
+		if err != nil {
+			return fmt.Errorf("line %d: unterminated comment", l.line)
+		}
+		if c == '\n' {
+			l.line++
+		}
+		if previous == '*' && c == '/' {
+			return nil
+		}
+		previous = c
+	}
+}
+
+// quoted reads a double-quoted string, whose opening quote has been read
+func (l *dotLexer) quoted() (dotToken, error) {
+	tok := dotToken{quoted: true, line: l.line}
+	var text []rune
+	for {
+		c, _, err := l.r.ReadRune()
+		if err != nil {
+			return dotToken{}, fmt.Errorf("line %d: unterminated string", tok.line)
+		}
+		switch c {
+		case '"':
+			tok.text = string(text)
+			return tok, nil
+		case '\\':
+			d, _, err := l.r.ReadRune()
+			if err != nil {
+				return dotToken{}, fmt.Errorf("line %d: unterminated string", tok.line)
+			}
+			switch d {
+			case '"', '\\':
+				text = append(text, d)
+			case '\n':
+				l.line++ // line continuation
+			default:
+				text = append(text, c, d)
+			}
+		default:
+			if c == '\n' {
+				l.line++
+			}
+			text = append(text, c)
+		}
+	}
+}
+
+// word reads an unquoted ID starting with c: an identifier or a number
+func (l *dotLexer) word(c rune) (dotToken, error) {
+	text := []rune{c}
+	for {
+		d, _, err := l.r.ReadRune()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return dotToken{}, err
+		}
+		if !(d == '_' || d == '.' || unicode.IsLetter(d) || unicode.IsDigit(d)) {
+			l.r.UnreadRune()
+			break
+		}
+		text = append(text, d)
+	}
+	for _, r := range text {
+		if !(r == '_' || r == '.' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return dotToken{}, fmt.Errorf("line %d: unexpected character %q", l.line, r)
+		}
+	}
+	return dotToken{text: string(text), line: l.line}, nil
+}
+
+// dotParser parses DOT statements into a goraph.Graph
+type dotParser struct {
+	lex    *dotLexer
+	peeked *dotToken
+	g      goraph.Graph
+	edges  map[[2]string]int // line of every edge, to report duplicates
+}
+
+func (p *dotParser) next() (dotToken, error) {
+	if p.peeked != nil {
+		tok := *p.peeked
+		p.peeked = nil
+		return tok, nil
+	}
+	return p.lex.next()
+}
+
+func (p *dotParser) peek() (dotToken, error) {
+	if p.peeked == nil {
+		tok, err := p.lex.next()
+		if err != nil {
+			return tok, err
+		}
+		p.peeked = &tok
+	}
+	return *p.peeked, nil
+}
+
+// expect reads the next token, which must be text
+func (p *dotParser) expect(text string) error {
+	tok, err := p.next()
+	if err == io.EOF {
+		return fmt.Errorf("line %d: expected %q, got end of input", p.lex.line, text)
+	}
+	if err != nil {
+		return err
+	}
+	if tok.quoted || tok.text != text {
+		return fmt.Errorf("line %d: expected %q, got %q", tok.line, text, tok.text)
+	}
+	return nil
+}
+
+// punctuation lists the tokens which aren't IDs
+var punctuation = map[string]bool{
+	"{": true, "}": true, "[": true, "]": true, ";": true, ",": true, "=": true, ":": true, "->": true, "--": true,
+}
+
+// isID returns whether tok is an ID rather than punctuation
+func isID(tok dotToken) bool {
+	return tok.quoted || !punctuation[tok.text]
+}
+
+// ReadDOT reads a DOT digraph into a goraph.Graph
+// The weight of every edge is taken from its weight attribute, or from its label if it has none, which must be a number, edge statements setting defaults for the rest of their block.
+// In quoted IDs, \" and \\ stand for a quote and a backslash.
+// Subgraphs are flattened, and other attributes are ignored. Undirected graphs and edges defined twice are rejected.
+func ReadDOT(r io.Reader) (goraph.Graph, error) {
+	p := &dotParser{
+		lex:   &dotLexer{r: bufio.NewReader(r), line: 1},
+		g:     goraph.NewGraph(),
+		edges: make(map[[2]string]int),
+	}
+	if err := p.parseGraph(); err != nil {
+		return nil, fmt.Errorf("ReadDOT: %v", err)
+	}
+	return p.g, nil
+}
+
+// parseGraph parses the whole input
+func (p *dotParser) parseGraph() error {
+	tok, err := p.next()
+	if err == io.EOF {
+		return fmt.Errorf("empty input")
+	}
+	if err != nil {
+		return err
+	}
+	if !tok.quoted && strings.ToLower(tok.text) == "strict" {
+		if tok, err = p.next(); err != nil {
+			return err
+		}
+	}
+	switch strings.ToLower(tok.text) {
+	case "digraph":
+	case "graph":
+		return fmt.Errorf("line %d: undirected graphs can't have arborescences", tok.line)
+	default:
+		return fmt.Errorf("line %d: expected \"digraph\", got %q", tok.line, tok.text)
+	}
+	if tok, err = p.peek(); err == nil && isID(tok) {
+		p.next() // the name of the graph
+	}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	if err := p.parseStatements(nil); err != nil {
+		return err
+	}
+	if tok, err := p.next(); err != io.EOF {
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("line %d: unexpected %q after the graph", tok.line, tok.text)
+	}
+	return nil
+}
+
+// parseStatements parses statements until the closing brace
+// Edge statements set default edge attributes for the rest of the block, starting from those of the enclosing one, defined.
+func (p *dotParser) parseStatements(defined map[string]string) error {
+	scope := make(map[string]string, len(defined))
+	for k, v := range defined {
+		scope[k] = v
+	}
+	for {
+		tok, err := p.next()
+		if err == io.EOF {
+			return fmt.Errorf("line %d: missing closing brace", p.lex.line)
+		}
+		if err != nil {
+			return err
+		}
+		switch {
+		case !tok.quoted && tok.text == "}":
+			return nil
+		case !tok.quoted && tok.text == ";":
+		case !tok.quoted && tok.text == "{":
+			if err := p.parseStatements(scope); err != nil {
+				return err
+			}
+		case !tok.quoted && strings.ToLower(tok.text) == "subgraph":
+			if next, err := p.peek(); err == nil && isID(next) {
+				p.next()
+			}
+			if err := p.expect("{"); err != nil {
+				return err
+			}
+			if err := p.parseStatements(scope); err != nil {
+				return err
+			}
+		case !tok.quoted && (strings.ToLower(tok.text) == "graph" || strings.ToLower(tok.text) == "node"):
+			if _, err := p.parseAttributes(); err != nil {
+				return err
+			}
+		case !tok.quoted && strings.ToLower(tok.text) == "edge":
+			attrs, err := p.parseAttributes()
+			if err != nil {
+				return err
+			}
+			for k, v := range attrs {
+				scope[k] = v
+			}
+		case isID(tok):
+			if err := p.parseNodeOrEdge(tok, scope); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("line %d: unexpected %q", tok.line, tok.text)
+		}
+	}
+}
+
+// parseAttributes parses an optional list of attributes
+func (p *dotParser) parseAttributes() (map[string]string, error) {
+	attrs := make(map[string]string)
+	for {
+		tok, err := p.peek()
+		if err != nil || tok.quoted || tok.text != "[" {
+			return attrs, nil
+		}
+		p.next()
+		for {
+			key, err := p.next()
+			if err != nil {
+				return nil, fmt.Errorf("line %d: unterminated attribute list", p.lex.line)
+			}
+			if !key.quoted && key.text == "]" {
+				break
+			}
+			if !key.quoted && (key.text == "," || key.text == ";") {
+				continue
+			}
+			if !isID(key) {
+				return nil, fmt.Errorf("line %d: unexpected %q in attribute list", key.line, key.text)
+			}
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			value, err := p.next()
+			if err != nil || !isID(value) {
+				return nil, fmt.Errorf("line %d: missing value of attribute %s", key.line, key.text)
+			}
+			attrs[key.text] = value.text
+		}
+	}
+}
+
+// parseNodeOrEdge parses a statement starting with the given ID, defined being the default edge attributes
+func (p *dotParser) parseNodeOrEdge(first dotToken, defined map[string]string) error {
+	chain := []dotToken{first}
+	for {
+		tok, err := p.peek()
+		if err != nil || tok.quoted {
+			break
+		}
+		if tok.text == ":" {
+			return fmt.Errorf("line %d: ports aren't supported", tok.line)
+		}
+		if tok.text == "--" {
+			return fmt.Errorf("line %d: undirected edges can't be part of an arborescence", tok.line)
+		}
+		if tok.text != "->" {
+			break
+		}
+		p.next()
+		target, err := p.next()
+		if err != nil || !isID(target) {
+			return fmt.Errorf("line %d: expected a node after ->", tok.line)
+		}
+		chain = append(chain, target)
+	}
+	if next, err := p.peek(); err == nil && !next.quoted && next.text == "=" {
+		// A graph attribute, such as rankdir=LR
+		p.next()
+		if value, err := p.next(); err != nil || !isID(value) {
+			return fmt.Errorf("line %d: missing value of attribute %s", first.line, first.text)
+		}
+		return nil
+	}
+
+	attrs, err := p.parseAttributes()
+	if err != nil {
+		return err
+	}
+	for _, tok := range chain {
+		p.g.AddNode(goraph.NewNode(tok.text))
+	}
+	if len(chain) == 1 {
+		return nil
+	}
+
+	weight, err := edgeWeight(attrs, defined, first.line)
+	if err != nil {
+		return err
+	}
+	for i := 1; i < len(chain); i++ {
+		source, target := chain[i-1].text, chain[i].text
+		if line, ok := p.edges[[2]string{source, target}]; ok {
+			return fmt.Errorf("line %d: edge %s -> %s is already defined on line %d", first.line, source, target, line)
+		}
+		p.edges[[2]string{source, target}] = first.line
+		if err := p.g.AddEdge(goraph.StringID(source), goraph.StringID(target), weight); err != nil {
+			return fmt.Errorf("line %d: error while adding edge %s -> %s: %v", first.line, source, target, err)
+		}
+	}
+	return nil
+}
+
+// edgeWeight returns the weight of an edge with the given attributes, or the default ones
+func edgeWeight(attrs map[string]string, defined map[string]string, line int) (float64, error) {
+	for _, key := range []string{"weight", "label"} {
+		value, ok := attrs[key]
+		if !ok {
+			value, ok = defined[key]
+		}
+		if !ok {
+			continue
+		}
+		weight, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("line %d: %s %q isn't a number", line, key, value)
+		}
+		return weight, nil
+	}
+	return 0, fmt.Errorf("line %d: edge has neither a weight nor a label", line)
+}
+
+// WriteOption configures WriteDOT
+type WriteOption func(*writeConfig)
+
+type writeConfig struct {
+	clusters bool
+}
+
+// WithClusters draws the sets of nodes of the dual variables of the arborescence as nested clusters, labelled with their value
+// They are the supernodes contracted by Chu–Liu/Edmonds' algorithm. The arborescence must have been found with msa.WithDuals.
+func WithClusters() WriteOption {
+	return func(c *writeConfig) {
+		c.clusters = true
+	}
+}
+
+// WriteDOT writes g as a DOT digraph, every edge being labelled with its weight
+// If arb isn't nil, its edges are highlighted and its root is drawn with a double border
+func WriteDOT(w io.Writer, g goraph.Graph, arb *msa.Arborescence, opts ...WriteOption) error {
+	c := &writeConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	var ids []string
+	for id := range g.GetNodes() {
+		ids = append(ids, id.String())
+	}
+	sort.Strings(ids)
+	edges, err := msa.GetEdges(g)
+	if err != nil {
+		return fmt.Errorf("WriteDOT: error while retrieving edges: %v", err)
+	}
+	sort.Sort(edgesByID(edges))
+
+	inTree := make(map[[2]string]struct{})
+	var root string
+	if arb != nil {
+		root = arb.Root.String()
+		for _, e := range arb.Edges {
+			inTree[[2]string{e.Source().ID().String(), e.Target().ID().String()}] = struct{}{}
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph {")
+
+	// Nodes, inside the clusters they belong to
+	var (
+		clusters []cluster
+		outside  = ids
+	)
+	if c.clusters && arb != nil {
+		clusters, outside = newClusters(arb.Duals, ids)
+	}
+	writeNode := func(indent string, id string) {
+		if arb != nil && id == root {
+			fmt.Fprintf(bw, "%s%s [peripheries=2, style=bold];\n", indent, quote(id))
+		} else {
+			fmt.Fprintf(bw, "%s%s;\n", indent, quote(id))
+		}
+	}
+	var writeCluster func(indent string, k int)
+	writeCluster = func(indent string, k int) {
+		fmt.Fprintf(bw, "%ssubgraph cluster_%d {\n", indent, k)
+		fmt.Fprintf(bw, "%s\tlabel=%s;\n", indent, quote(strconv.FormatFloat(clusters[k].value, 'g', -1, 64)))
+		for _, child := range clusters[k].children {
+			writeCluster(indent+"\t", child)
+		}
+		for _, id := range clusters[k].nodes {
+			writeNode(indent+"\t", id)
+		}
+		fmt.Fprintf(bw, "%s}\n", indent)
+	}
+	for k := range clusters {
+		if clusters[k].parent < 0 {
+			writeCluster("\t", k)
+		}
+	}
+	for _, id := range outside {
+		writeNode("\t", id)
+	}
+
+	// Edges
+	for _, e := range edges {
+		source, target := e.Source().ID().String(), e.Target().ID().String()
+		fmt.Fprintf(bw, "\t%s -> %s [label=%s", quote(source), quote(target), quote(strconv.FormatFloat(e.Weight(), 'g', -1, 64)))
+		if _, ok := inTree[[2]string{source, target}]; ok {
+			fmt.Fprint(bw, ", color=red, penwidth=2")
+		} else if arb != nil {
+			fmt.Fprint(bw, ", color=gray")
+		}
+		fmt.Fprintln(bw, "];")
+	}
+
+	fmt.Fprintln(bw, "}")
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("WriteDOT: %v", err)
+	}
+	return nil
+}
+
+// cluster is a set of nodes of a dual variable, drawn as a cluster
+type cluster struct {
+	value    float64
+	parent   int
+	children []int
+	nodes    []string // the nodes directly in it, not in its children
+}
+
+// newClusters builds the clusters of the dual variables of several nodes, which are laminar, returning the nodes outside of every cluster as well
+func newClusters(duals []msa.Dual, ids []string) ([]cluster, []string) {
+	var sets []msa.Dual
+	for _, d := range duals {
+		if len(d.Nodes) > 1 {
+			sets = append(sets, d)
+		}
+	}
+	// From the largest to the smallest, so that every cluster comes after its parent
+	sort.Stable(dualsBySize(sets))
+
+	clusters := make([]cluster, len(sets))
+	inner := make(map[string]int, len(ids))
+	for k, d := range sets {
+		clusters[k].value = d.Value
+		parent, ok := inner[d.Nodes[0].String()]
+		if !ok {
+			parent = -1
+		}
+		clusters[k].parent = parent
+		if parent >= 0 {
+			clusters[parent].children = append(clusters[parent].children, k)
+		}
+		for _, id := range d.Nodes {
+			inner[id.String()] = k
+		}
+	}
+
+	var outside []string
+	for _, id := range ids {
+		if k, ok := inner[id]; ok {
+			clusters[k].nodes = append(clusters[k].nodes, id)
+		} else {
+			outside = append(outside, id)
+		}
+	}
+	return clusters, outside
+}
+
+// quote returns id as a DOT quoted string, escaping quotes and backslashes
+func quote(id string) string {
+	return `"` + quoteReplacer.Replace(id) + `"`
+}
+
+// quoteReplacer escapes the characters of a DOT quoted string
+var quoteReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// dualsBySize sorts dual variables by decreasing size of their set
+type dualsBySize []msa.Dual
+
+func (s dualsBySize) Len() int           { return len(s) }
+func (s dualsBySize) Less(i, j int) bool { return len(s[i].Nodes) > len(s[j].Nodes) }
+func (s dualsBySize) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// edgesByID sorts edges by source ID, then target ID
+type edgesByID []goraph.Edge
+
+func (s edgesByID) Len() int { return len(s) }
+func (s edgesByID) Less(i, j int) bool {
+	si, sj := s[i].Source().ID().String(), s[j].Source().ID().String()
+	return si < sj || (si == sj && s[i].Target().ID().String() < s[j].Target().ID().String())
+}
+func (s edgesByID) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
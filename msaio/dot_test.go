@@ -0,0 +1,240 @@
+package msaio
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/aabizri/msa"
+	"github.com/gyuho/goraph"
+	"os"
+	"strings"
+	"testing"
+)
+
+// loadGraph loads the graph with the given ID from the testdata of package msa
+func loadGraph(t *testing.T, graphID string) goraph.Graph {
+	f, err := os.Open("../testdata/graph.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	g, err := goraph.NewGraphFromJSON(f, graphID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// edgeSet returns the edges of g as "source target weight" strings
+func edgeSet(t *testing.T, g goraph.Graph) map[string]bool {
+	edges, err := msa.GetEdges(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := make(map[string]bool, len(edges))
+	for _, e := range edges {
+		set[fmt.Sprintf("%s %s %v", e.Source().ID(), e.Target().ID(), e.Weight())] = true
+	}
+	return set
+}
+
+// Test that writing then reading the testdata graphs gives them back
+func TestDOT_RoundTrip(t *testing.T) {
+	for i := 0; i <= 17; i++ {
+		graphID := fmt.Sprintf("graph_%02d", i)
+		g := loadGraph(t, graphID)
+		var buf bytes.Buffer
+		if err := WriteDOT(&buf, g, nil); err != nil {
+			t.Fatalf("%s: %v", graphID, err)
+		}
+		h, err := ReadDOT(&buf)
+		if err != nil {
+			t.Fatalf("%s: %v", graphID, err)
+		}
+		if len(h.GetNodes()) != len(g.GetNodes()) {
+			t.Errorf("%s: read %d nodes, expected %d", graphID, len(h.GetNodes()), len(g.GetNodes()))
+		}
+		expected, got := edgeSet(t, g), edgeSet(t, h)
+		if len(got) != len(expected) {
+			t.Errorf("%s: read %d edges, expected %d", graphID, len(got), len(expected))
+		}
+		for e := range expected {
+			if !got[e] {
+				t.Errorf("%s: edge %s is missing", graphID, e)
+			}
+		}
+	}
+}
+
+// Test that IDs with quotes and backslashes are read back as written
+func TestDOT_RoundTripEscapes(t *testing.T) {
+	ids := []string{`a\`, `b"c`, `\"`, `d\\e`, `\n`}
+	g := goraph.NewGraph()
+	for _, id := range ids {
+		g.AddNode(goraph.NewNode(id))
+	}
+	for i := 1; i < len(ids); i++ {
+		if err := g.AddEdge(goraph.StringID(ids[i-1]), goraph.StringID(ids[i]), float64(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteDOT(&buf, g, nil); err != nil {
+		t.Fatal(err)
+	}
+	h, err := ReadDOT(&buf)
+	if err != nil {
+		t.Fatalf("%v, reading:\n%s", err, buf.String())
+	}
+	expected, got := edgeSet(t, g), edgeSet(t, h)
+	if len(got) != len(expected) {
+		t.Errorf("read edges %v, expected %v", got, expected)
+	}
+	for e := range expected {
+		if !got[e] {
+			t.Errorf("edge %s is missing", e)
+		}
+	}
+}
+
+func TestReadDOT(t *testing.T) {
+	const input = `/* Example */
+strict digraph "example" {
+	rankdir=LR; // left to right
+	node [shape=circle]
+	"root" -> a [weight=2.5, color=blue]
+	# a comment
+	a -> b -> "c d" [label="-1"]
+	subgraph cluster_0 {
+		edge [weight=0]
+		b -> a
+		e
+	}
+	{ "c d" -> "q\"uote" [label=x, weight=7] }
+}
+`
+	g, err := ReadDOT(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(g.GetNodes()); n != 6 {
+		t.Errorf("read %d nodes, expected 6", n)
+	}
+	expected := map[string]bool{
+		"root a 2.5":   true,
+		"a b -1":       true,
+		"b c d -1":     true,
+		"b a 0":        true,
+		`c d q"uote 7`: true,
+	}
+	got := edgeSet(t, g)
+	if len(got) != len(expected) {
+		t.Errorf("read edges %v, expected %v", got, expected)
+	}
+	for e := range expected {
+		if !got[e] {
+			t.Errorf("edge %s is missing", e)
+		}
+	}
+}
+
+func TestReadDOT_Errors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"graph { a -- b [weight=1] }", "line 1: undirected graphs"},
+		{"digraph {\n a -> b [weight=1]\n a -- b }", "line 3: undirected edges"},
+		{"digraph {\n\n a -> b\n}", "line 3: edge has neither a weight nor a label"},
+		{"digraph {\n a -> b [weight=heavy]\n}", `line 2: weight "heavy" isn't a number`},
+		{"digraph {\n a -> b [weight=1]\n a -> b [weight=2]\n}", "line 3: edge a -> b is already defined on line 2"},
+		{"digraph {\n a -> b [weight=1]\n", "missing closing brace"},
+		{"digraph {\n a -> [weight=1]\n}", "line 2: expected a node after ->"},
+		{"digraph {\n a:n -> b [weight=1]\n}", "line 2: ports aren't supported"},
+		{"digraph {\n a -> b [weight=1]\n} }", `line 3: unexpected "}" after the graph`},
+		{"digraph {\n \"a\n -> b [weight=1]\n}", "line 2: unterminated string"},
+		{"digraph {\n subgraph s { edge [weight=5]; a -> b }\n c -> d\n}", "line 3: edge has neither a weight nor a label"},
+	}
+	for _, test := range tests {
+		_, err := ReadDOT(strings.NewReader(test.input))
+		if err == nil {
+			t.Errorf("%q: expected an error", test.input)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%q: got error %q, expected it to contain %q", test.input, err, test.err)
+		}
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	g := loadGraph(t, "graph_17")
+	arb, err := msa.Solve(g, goraph.StringID("A"), msa.WithDuals())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteDOT(&buf, g, arb); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `"A" [peripheries=2, style=bold];`) {
+		t.Errorf("the root isn't marked:\n%s", out)
+	}
+	if strings.Contains(out, "subgraph") {
+		t.Errorf("clusters are drawn without WithClusters:\n%s", out)
+	}
+	for _, e := range arb.Edges {
+		highlighted := fmt.Sprintf("%q -> %q [label=%q, color=red, penwidth=2];", e.Source().ID().String(), e.Target().ID().String(), fmt.Sprint(e.Weight()))
+		if !strings.Contains(out, highlighted) {
+			t.Errorf("edge %s -> %s isn't highlighted:\n%s", e.Source().ID(), e.Target().ID(), out)
+		}
+	}
+	if n := strings.Count(out, "color=red"); n != len(arb.Edges) {
+		t.Errorf("%d edges are highlighted, expected %d", n, len(arb.Edges))
+	}
+
+	// The output must be readable and describe the same graph
+	h, err := ReadDOT(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edgeSet(t, h)) != len(edgeSet(t, g)) {
+		t.Errorf("reading the output gives a different graph")
+	}
+}
+
+// Test that clusters match the dual variables of contracted cycles, and are properly nested
+func TestWriteDOT_Clusters(t *testing.T) {
+	for i := 0; i <= 17; i++ {
+		graphID := fmt.Sprintf("graph_%02d", i)
+		g := loadGraph(t, graphID)
+		for root := range g.GetNodes() {
+			arb, err := msa.Solve(g, root, msa.WithDuals())
+			if err != nil {
+				continue
+			}
+			var buf bytes.Buffer
+			if err := WriteDOT(&buf, g, arb, WithClusters()); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+
+			var sets int
+			for _, d := range arb.Duals {
+				if len(d.Nodes) > 1 {
+					sets++
+				}
+			}
+			if n := strings.Count(out, "subgraph cluster_"); n != sets {
+				t.Errorf("%s rooted at %s: %d clusters, expected %d", graphID, root, n, sets)
+			}
+			if strings.Count(out, "{") != strings.Count(out, "}") {
+				t.Errorf("%s rooted at %s: unbalanced braces:\n%s", graphID, root, out)
+			}
+			if _, err := ReadDOT(strings.NewReader(out)); err != nil {
+				t.Errorf("%s rooted at %s: %v\n%s", graphID, root, err, out)
+			}
+		}
+	}
+}
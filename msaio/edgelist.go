@@ -0,0 +1,423 @@
+package msaio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"github.com/aabizri/msa"
+	"github.com/gyuho/goraph"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// This file implements reading and writing delimited edge lists, such as the CSV files exported from SQL databases
+
+// HeaderMode tells whether an edge list starts with a header row
+type HeaderMode int
+
+const (
+	// DetectHeader treats the first row as a header if its weight isn't a number and it names a column source, target, weight, id or label
+	DetectHeader HeaderMode = iota
+
+	// WithHeader treats the first row as a header
+	WithHeader
+
+	// NoHeader treats every row as an edge
+	NoHeader
+)
+
+// EdgeListFormat describes the rows of a delimited edge list
+type EdgeListFormat struct {
+	// Comma separates the fields of a row, which may be quoted as in CSV, and then hold line breaks
+	Comma rune
+
+	// Header tells whether the first row is a header
+	// When reading, a header naming some of its columns source, target, weight, id or label overrides the indexes below.
+	// When writing, a header is written unless it is NoHeader.
+	Header HeaderMode
+
+	// The indexes of the columns, starting at 0
+	// ID and Label are optional, and are -1 when absent.
+	Source, Target, Weight, ID, Label int
+}
+
+var (
+	// CSV is the format of comma-separated source,target,weight rows
+	CSV = EdgeListFormat{Comma: ',', Source: 0, Target: 1, Weight: 2, ID: -1, Label: -1}
+
+	// TSV is the format of tab-separated source, target and weight rows
+	TSV = EdgeListFormat{Comma: '\t', Source: 0, Target: 1, Weight: 2, ID: -1, Label: -1}
+)
+
+// columns returns the indexes of the columns, in order, along with their names
+func (f EdgeListFormat) columns() ([]int, []string) {
+	return []int{f.Source, f.Target, f.Weight, f.ID, f.Label}, []string{"source", "target", "weight", "id", "label"}
+}
+
+// check checks that the format is valid
+func (f EdgeListFormat) check() error {
+	if f.Comma == '"' || f.Comma == '\n' || f.Comma == '\r' || f.Comma == 0 {
+		return fmt.Errorf("invalid separator %q", f.Comma)
+	}
+	indexes, names := f.columns()
+	seen := make(map[int]string, len(indexes))
+	for k, i := range indexes {
+		if i < 0 {
+			if k < 3 {
+				return fmt.Errorf("the %s column is required", names[k])
+			}
+			continue
+		}
+		if other, ok := seen[i]; ok {
+			return fmt.Errorf("the %s and %s columns are both at index %d", other, names[k], i)
+		}
+		seen[i] = names[k]
+	}
+	return nil
+}
+
+// width returns the number of fields needed to hold every column
+func (f EdgeListFormat) width() int {
+	indexes, _ := f.columns()
+	width := 0
+	for _, i := range indexes {
+		if i+1 > width {
+			width = i + 1
+		}
+	}
+	return width
+}
+
+// EdgeListReader reads edges from a delimited edge list, one per row
+type EdgeListReader struct {
+	s      *bufio.Scanner
+	f      EdgeListFormat
+	line   int  // the line the last row read starts on
+	lines  int  // the number of lines read
+	header bool // whether the header, if any, was read
+}
+
+// NewEdgeListReader returns an EdgeListReader reading rows of the given format from r
+func NewEdgeListReader(r io.Reader, f EdgeListFormat) *EdgeListReader {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1<<20)
+	return &EdgeListReader{s: s, f: f}
+}
+
+// Read reads the next edge, returning io.EOF when there are none left
+// The edge is an msa.IdentifiedEdge if it has an ID, and an msa.LabeledEdge if it has a label. Blank lines are skipped.
+// As with encoding/csv, a carriage return ending a line is dropped, even in a quoted field.
+func (r *EdgeListReader) Read() (goraph.Edge, error) {
+	if !r.header {
+		if err := r.f.check(); err != nil {
+			return nil, fmt.Errorf("EdgeListReader.Read: %v", err)
+		}
+	}
+	for {
+		fields, err := r.readRow()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("EdgeListReader.Read: %v", err)
+		}
+		if !r.header {
+			r.header = true
+			if r.isHeader(fields) {
+				if err := r.readHeader(fields); err != nil {
+					return nil, fmt.Errorf("EdgeListReader.Read: line %d: %v", r.line, err)
+				}
+				continue
+			}
+		}
+		e, err := r.parseRow(fields)
+		if err != nil {
+			return nil, fmt.Errorf("EdgeListReader.Read: line %d: %v", r.line, err)
+		}
+		return e, nil
+	}
+}
+
+// readRow reads the fields of the next row that isn't blank, returning io.EOF when there are none left
+// Lines are joined as long as a quoted field is left open, that is while they hold an odd number of quotes, and the row is then parsed by encoding/csv.
+func (r *EdgeListReader) readRow() ([]string, error) {
+	for {
+		if !r.s.Scan() {
+			if err := r.s.Err(); err != nil {
+				return nil, fmt.Errorf("line %d: %v", r.lines+1, err)
+			}
+			return nil, io.EOF
+		}
+		r.lines++
+		if strings.TrimSpace(r.s.Text()) != "" {
+			break
+		}
+	}
+	r.line = r.lines
+	row := r.s.Text()
+	for quotes := strings.Count(row, `"`); quotes%2 != 0 && r.s.Scan(); quotes += strings.Count(r.s.Text(), `"`) {
+		r.lines++
+		row += "\n" + r.s.Text()
+	}
+	if err := r.s.Err(); err != nil {
+		return nil, fmt.Errorf("line %d: %v", r.lines+1, err)
+	}
+
+	cr := csv.NewReader(strings.NewReader(row))
+	cr.Comma = r.f.Comma
+	cr.FieldsPerRecord = -1
+	fields, err := cr.Read()
+	if pe, ok := err.(*csv.ParseError); ok {
+		return nil, fmt.Errorf("line %d: %v", r.line+pe.Line-1, pe.Err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %v", r.line, err)
+	}
+	return fields, nil
+}
+
+// Line returns the line the last row read starts on
+func (r *EdgeListReader) Line() int {
+	return r.line
+}
+
+// isHeader returns whether the first row is a header
+func (r *EdgeListReader) isHeader(fields []string) bool {
+	switch r.f.Header {
+	case WithHeader:
+		return true
+	case NoHeader:
+		return false
+	}
+	if r.f.Weight < len(fields) {
+		if _, err := strconv.ParseFloat(strings.TrimSpace(fields[r.f.Weight]), 64); err == nil {
+			return false
+		}
+	}
+
+	// Otherwise the row is a malformed edge, unless it names a column
+	_, names := r.f.columns()
+	for _, field := range fields {
+		field = strings.ToLower(strings.TrimSpace(field))
+		for _, name := range names {
+			if field == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readHeader sets the indexes of the columns named in the header
+func (r *EdgeListReader) readHeader(fields []string) error {
+	f := r.f
+	named := make(map[string]bool, len(fields))
+	for i, name := range fields {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if named[name] && name != "" {
+			return fmt.Errorf("invalid header: column %s appears twice", name)
+		}
+		named[name] = true
+		switch name {
+		case "source":
+			f.Source = i
+		case "target":
+			f.Target = i
+		case "weight":
+			f.Weight = i
+		case "id":
+			f.ID = i
+		case "label":
+			f.Label = i
+		}
+	}
+	if err := f.check(); err != nil {
+		return fmt.Errorf("invalid header: %v", err)
+	}
+	r.f = f
+	return nil
+}
+
+// parseRow parses the fields of a row into an edge
+func (r *EdgeListReader) parseRow(fields []string) (goraph.Edge, error) {
+	if width := r.f.width(); len(fields) < width {
+		return nil, fmt.Errorf("expected at least %d fields, got %d", width, len(fields))
+	}
+	source, target := fields[r.f.Source], fields[r.f.Target]
+	if source == "" || target == "" {
+		return nil, fmt.Errorf("empty node ID")
+	}
+	value := strings.TrimSpace(fields[r.f.Weight])
+	weight, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("weight %q isn't a number", value)
+	}
+	if math.IsNaN(weight) || math.IsInf(weight, 0) {
+		return nil, fmt.Errorf("weight %q isn't a finite number", value)
+	}
+
+	sourceNode, targetNode := goraph.NewNode(source), goraph.NewNode(target)
+	var e goraph.Edge = goraph.NewEdge(sourceNode, targetNode, weight)
+	if r.f.Label >= 0 && fields[r.f.Label] != "" {
+		e = msa.NewLabeledEdge(sourceNode, targetNode, weight, fields[r.f.Label])
+	}
+	if r.f.ID >= 0 && fields[r.f.ID] != "" {
+		e = msa.NewIdentifiedEdge(e, fields[r.f.ID])
+	}
+	return e, nil
+}
+
+// ReadEdgeList reads a delimited edge list into a msa.Multigraph, keeping parallel edges apart
+// Edges without an ID are numbered in the order of their rows, starting at 0 and skipping the IDs already taken, as msa.Multigraph.AddEdge does.
+func ReadEdgeList(r io.Reader, f EdgeListFormat) (*msa.Multigraph, error) {
+	m := msa.NewMultigraph()
+	er := NewEdgeListReader(r, f)
+	for {
+		e, err := er.Read()
+		if err == io.EOF {
+			return m, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ReadEdgeList: %v", err)
+		}
+		if _, err := m.AddEdge(e); err != nil {
+			return nil, fmt.Errorf("ReadEdgeList: line %d: %v", er.Line(), err)
+		}
+	}
+}
+
+// ReadEdgeListGraph reads a delimited edge list into a goraph.Graph, as used by msa.MSA and msa.MSAAllRoots
+// A goraph.Graph can't hold parallel edges, nor IDs and labels: the former are rejected, the latter ignored.
+func ReadEdgeListGraph(r io.Reader, f EdgeListFormat) (goraph.Graph, error) {
+	g := goraph.NewGraph()
+	lines := make(map[[2]string]int)
+	er := NewEdgeListReader(r, f)
+	for {
+		e, err := er.Read()
+		if err == io.EOF {
+			return g, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ReadEdgeListGraph: %v", err)
+		}
+		source, target := e.Source().ID().String(), e.Target().ID().String()
+		if line, ok := lines[[2]string{source, target}]; ok {
+			return nil, fmt.Errorf("ReadEdgeListGraph: line %d: edge going from %s to %s is already defined on line %d", er.Line(), source, target, line)
+		}
+		lines[[2]string{source, target}] = er.Line()
+		g.AddNode(goraph.NewNode(source))
+		g.AddNode(goraph.NewNode(target))
+		if err := g.AddEdge(goraph.StringID(source), goraph.StringID(target), e.Weight()); err != nil {
+			return nil, fmt.Errorf("ReadEdgeListGraph: line %d: error while adding edge going from %s to %s: %v", er.Line(), source, target, err)
+		}
+	}
+}
+
+// EdgeListWriter writes edges as a delimited edge list, one per row
+type EdgeListWriter struct {
+	w      *bufio.Writer
+	f      EdgeListFormat
+	header bool // whether the header, if any, was written
+}
+
+// NewEdgeListWriter returns an EdgeListWriter writing rows of the given format to w
+// Writes are buffered, call Flush once done.
+func NewEdgeListWriter(w io.Writer, f EdgeListFormat) *EdgeListWriter {
+	return &EdgeListWriter{w: bufio.NewWriter(w), f: f}
+}
+
+// Write writes e, preceded by the header if it is the first edge written
+// The ID of an msa.IdentifiedEdge and the label of an msa.LabeledEdge are written in their columns, if the format has them.
+func (w *EdgeListWriter) Write(e goraph.Edge) error {
+	if err := w.writeHeader(); err != nil {
+		return fmt.Errorf("EdgeListWriter.Write: %v", err)
+	}
+
+	var id, label string
+	if ie, ok := e.(msa.IdentifiedEdge); ok {
+		id = ie.EdgeID()
+	}
+	if le, ok := e.(msa.LabeledEdge); ok {
+		label = le.Label()
+	}
+	indexes, _ := w.f.columns()
+	values := []string{e.Source().ID().String(), e.Target().ID().String(), strconv.FormatFloat(e.Weight(), 'g', -1, 64), id, label}
+	if err := w.writeRow(indexes, values); err != nil {
+		return fmt.Errorf("EdgeListWriter.Write: %v", err)
+	}
+	return nil
+}
+
+// writeHeader checks the format and writes the header, unless it was already written or the format has none
+func (w *EdgeListWriter) writeHeader() error {
+	if w.header {
+		return nil
+	}
+	if err := w.f.check(); err != nil {
+		return err
+	}
+	w.header = true
+	if w.f.Header == NoHeader {
+		return nil
+	}
+	indexes, names := w.f.columns()
+	return w.writeRow(indexes, names)
+}
+
+// writeRow writes a row whose column at indexes[k] holds values[k], negative indexes being skipped
+func (w *EdgeListWriter) writeRow(indexes []int, values []string) error {
+	fields := make([]string, w.f.width())
+	for k, i := range indexes {
+		if i >= 0 {
+			fields[i] = w.quote(values[k])
+		}
+	}
+	_, err := w.w.WriteString(strings.Join(fields, string(w.f.Comma)) + "\n")
+	return err
+}
+
+// quote quotes a field if it needs to be
+func (w *EdgeListWriter) quote(field string) string {
+	if field == "" || (!strings.ContainsAny(field, string(w.f.Comma)+"\"\r\n") && strings.TrimSpace(field) == field) {
+		return field
+	}
+	return `"` + strings.Replace(field, `"`, `""`, -1) + `"`
+}
+
+// Flush writes any buffered data, as well as the header if no edge was written
+func (w *EdgeListWriter) Flush() error {
+	if err := w.writeHeader(); err != nil {
+		return fmt.Errorf("EdgeListWriter.Flush: %v", err)
+	}
+	if err := w.w.Flush(); err != nil {
+		return fmt.Errorf("EdgeListWriter.Flush: %v", err)
+	}
+	return nil
+}
+
+// WriteEdgeList writes edges as a delimited edge list
+func WriteEdgeList(w io.Writer, edges []goraph.Edge, f EdgeListFormat) error {
+	ew := NewEdgeListWriter(w, f)
+	for _, e := range edges {
+		if err := ew.Write(e); err != nil {
+			return fmt.Errorf("WriteEdgeList: %v", err)
+		}
+	}
+	if err := ew.Flush(); err != nil {
+		return fmt.Errorf("WriteEdgeList: %v", err)
+	}
+	return nil
+}
+
+// WriteArborescence writes the edges of arb as a delimited edge list, sorted by source then target
+func WriteArborescence(w io.Writer, arb *msa.Arborescence, f EdgeListFormat) error {
+	edges := append([]goraph.Edge(nil), arb.Edges...)
+	sort.Sort(edgesByID(edges))
+	if err := WriteEdgeList(w, edges, f); err != nil {
+		return fmt.Errorf("WriteArborescence: %v", err)
+	}
+	return nil
+}
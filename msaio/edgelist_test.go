@@ -0,0 +1,209 @@
+package msaio
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"github.com/aabizri/msa"
+	"github.com/gyuho/goraph"
+	"strings"
+	"testing"
+)
+
+// Test that writing then reading the testdata graphs gives them back
+func TestEdgeList_RoundTrip(t *testing.T) {
+	for _, format := range []EdgeListFormat{CSV, TSV, {Comma: ';', Header: NoHeader, Source: 2, Target: 0, Weight: 1, ID: -1, Label: -1}} {
+		for i := 0; i <= 17; i++ {
+			graphID := fmt.Sprintf("graph_%02d", i)
+			g := loadGraph(t, graphID)
+			edges, err := msa.GetEdges(g)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := WriteEdgeList(&buf, edges, format); err != nil {
+				t.Fatalf("%s: %v", graphID, err)
+			}
+			h, err := ReadEdgeListGraph(&buf, format)
+			if err != nil {
+				t.Fatalf("%s: %v", graphID, err)
+			}
+			expected, got := edgeSet(t, g), edgeSet(t, h)
+			if len(got) != len(expected) {
+				t.Errorf("%s: read %d edges, expected %d", graphID, len(got), len(expected))
+			}
+			for e := range expected {
+				if !got[e] {
+					t.Errorf("%s: edge %s is missing", graphID, e)
+				}
+			}
+
+			// The graph read can be solved for every root at once
+			expectedFeasible, _, _, err := msa.MSAAllRoots(g)
+			if err != nil {
+				t.Fatal(err)
+			}
+			feasible, _, _, err := msa.MSAAllRoots(h)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if feasible != expectedFeasible {
+				t.Errorf("%s: MSAAllRoots on the graph read gives feasible = %v, expected %v", graphID, feasible, expectedFeasible)
+			}
+		}
+	}
+}
+
+// Test that fields holding separators, quotes and line breaks are read back as written
+func TestEdgeList_RoundTripQuoted(t *testing.T) {
+	labels := []string{"two\nlines", "carriage\rreturn", `"quoted"`, "comma, separated", "tab\tseparated", " padded ", "blank\n\nline"}
+	var edges []goraph.Edge
+	for i, label := range labels {
+		source, target := goraph.NewNode(fmt.Sprintf("%d\n%q", i, label)), goraph.NewNode(label)
+		edges = append(edges, msa.NewIdentifiedEdge(msa.NewLabeledEdge(source, target, float64(i), label), label+" id"))
+	}
+
+	for _, format := range []EdgeListFormat{
+		{Comma: ',', Source: 0, Target: 1, Weight: 2, ID: 3, Label: 4},
+		{Comma: '\t', Header: NoHeader, Source: 4, Target: 3, Weight: 2, ID: 1, Label: 0},
+	} {
+		var buf bytes.Buffer
+		if err := WriteEdgeList(&buf, edges, format); err != nil {
+			t.Fatal(err)
+		}
+		written := buf.String()
+		m, err := ReadEdgeList(&buf, format)
+		if err != nil {
+			t.Fatalf("%v, reading:\n%s", err, written)
+		}
+		for _, e := range edges {
+			id := e.(msa.IdentifiedEdge).EdgeID()
+			got, ok := m.Edge(id)
+			if !ok {
+				t.Errorf("edge %q is missing, reading:\n%s", id, written)
+				continue
+			}
+			if got.Source().ID().String() != e.Source().ID().String() || got.Target().ID().String() != e.Target().ID().String() ||
+				got.Weight() != e.Weight() || got.(msa.LabeledEdge).Label() != e.(msa.LabeledEdge).Label() {
+				t.Errorf("read %q, expected %q", got, e)
+			}
+		}
+	}
+}
+
+func TestReadEdgeList(t *testing.T) {
+	const input = "Label,Weight,Target,Source,ID\r\n" +
+		"fast,3,B,A,link-1\r\n" +
+		"slow,1,B,A,link-2\r\n" +
+		"\r\n" +
+		"\"quoted, \"\"label\"\"\",2,C,B,link-3\r\n" +
+		",5,C,A,link-4\r\n"
+	m, err := ReadEdgeList(strings.NewReader(input), CSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(m.Nodes()); n != 3 {
+		t.Errorf("read %d nodes, expected 3", n)
+	}
+	if edges, _ := m.Incoming(goraph.StringID("B")); len(edges) != 2 {
+		t.Errorf("read %d parallel edges going into B, expected 2", len(edges))
+	}
+	if e, ok := m.Edge("link-3"); !ok || e.(msa.LabeledEdge).Label() != `quoted, "label"` {
+		t.Errorf("misread edge link-3: %v", e)
+	}
+	if e, _ := m.Edge("link-4"); e != nil {
+		if _, ok := e.(msa.LabeledEdge); ok {
+			t.Errorf("edge link-4 has an empty label")
+		}
+	}
+
+	arb, err := msa.SolveGraph(m, goraph.StringID("A"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	format := EdgeListFormat{Comma: '\t', Source: 0, Target: 1, Weight: 2, ID: 3, Label: 4}
+	if err := WriteArborescence(&buf, arb, format); err != nil {
+		t.Fatal(err)
+	}
+	const expected = "source\ttarget\tweight\tid\tlabel\n" +
+		"A\tB\t1\tlink-2\tslow\n" +
+		"B\tC\t2\tlink-3\t\"quoted, \"\"label\"\"\"\n"
+	if buf.String() != expected {
+		t.Errorf("got:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+
+	// The arborescence written can be read back
+	tree, err := ReadEdgeList(&buf, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := tree.Edge("link-3"); !ok || e.(msa.LabeledEdge).Label() != `quoted, "label"` {
+		t.Errorf("misread edge link-3 of the arborescence: %v", e)
+	}
+}
+
+// Test that edges without an ID don't take the ID of another one
+func TestReadEdgeList_AutomaticIDs(t *testing.T) {
+	format := EdgeListFormat{Comma: ',', Header: NoHeader, Source: 0, Target: 1, Weight: 2, ID: 3, Label: -1}
+	m, err := ReadEdgeList(strings.NewReader("a,b,1,1\nb,c,2,\nc,d,3,\n"), format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, expected := range map[string]string{"0": "b c", "1": "a b", "2": "c d"} {
+		e, ok := m.Edge(id)
+		if !ok {
+			t.Errorf("edge %s is missing", id)
+			continue
+		}
+		if got := e.Source().ID().String() + " " + e.Target().ID().String(); got != expected {
+			t.Errorf("edge %s goes from %s, expected %s", id, got, expected)
+		}
+	}
+}
+
+func TestReadEdgeList_Errors(t *testing.T) {
+	tests := []struct {
+		input  string
+		format EdgeListFormat
+		err    string
+	}{
+		{"source,target,weight\nA,B,1\nA,B\n", CSV, "line 3: expected at least 3 fields, got 2"},
+		{"A,B,1\nB,C,heavy\n", CSV, `line 2: weight "heavy" isn't a number`},
+		{"a,b,oops\nb,c,1\n", CSV, `line 1: weight "oops" isn't a number`},
+		{"A,B\nB,C,1\n", CSV, "line 1: expected at least 3 fields, got 2"},
+		{"A,B,NaN\n", CSV, `line 1: weight "NaN" isn't a finite number`},
+		{"A,B,1\n,C,1\n", CSV, "line 2: empty node ID"},
+		{"A,B,1\n\"A,B,1\n", CSV, "line 2: " + csv.ErrQuote.Error()},
+		{"A,B,1\n\"A\"x,B,1\n", CSV, "line 2: " + csv.ErrQuote.Error()},
+		{"A,B\"x\",1\n", CSV, "line 1: " + csv.ErrBareQuote.Error()},
+		{"\"A\nB\",C,1\n\nC,D,x\n", CSV, `line 4: weight "x" isn't a number`},
+		{"A,B,1,e\nB,C,1,e\n", EdgeListFormat{Comma: ',', Source: 0, Target: 1, Weight: 2, ID: 3, Label: -1}, "line 2: AddEdge: there is already an edge with ID e"},
+		{"source,target,weight,source\n", CSV, "line 1: invalid header"},
+		{"A,B,1\n", EdgeListFormat{Comma: ',', Source: 0, Target: 0, Weight: 2, ID: -1, Label: -1}, "the source and target columns are both at index 0"},
+	}
+	for _, test := range tests {
+		_, err := ReadEdgeList(strings.NewReader(test.input), test.format)
+		if err == nil {
+			t.Errorf("%q: expected an error", test.input)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%q: got error %q, expected it to contain %q", test.input, err, test.err)
+		}
+	}
+
+	if _, err := ReadEdgeListGraph(strings.NewReader("A,B,1\nB,C,2\nA,B,3\n"), CSV); err == nil || !strings.Contains(err.Error(), "line 3: edge going from A to B is already defined on line 1") {
+		t.Errorf("got error %v for parallel edges", err)
+	}
+}
+
+func TestWriteEdgeList_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteEdgeList(&buf, nil, CSV); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "source,target,weight\n" {
+		t.Errorf("got %q, expected only the header", buf.String())
+	}
+}
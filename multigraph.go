@@ -0,0 +1,132 @@
+package msa
+
+import (
+	"fmt"
+	"github.com/gyuho/goraph"
+	"strconv"
+)
+
+// IdentifiedEdge is an edge with an ID, telling it apart from the edges parallel to it
+// The solvers return the edges given by the Graph, so the ID of every edge of an arborescence says which of the parallel edges was selected.
+type IdentifiedEdge interface {
+	goraph.Edge
+
+	// EdgeID returns the ID of the edge
+	EdgeID() string
+}
+
+// identifiedEdge implements IdentifiedEdge
+type identifiedEdge struct {
+	goraph.Edge
+	id string
+}
+
+// EdgeID returns the ID of the edge
+func (e identifiedEdge) EdgeID() string {
+	return e.id
+}
+
+// String returns a representation of the edge, along with its ID
+func (e identifiedEdge) String() string {
+	return fmt.Sprintf("%s: %s", e.id, e.Edge.String())
+}
+
+// identifiedLabeledEdge is an identifiedEdge keeping the label of a LabeledEdge
+type identifiedLabeledEdge struct {
+	identifiedEdge
+}
+
+// Label returns the label of the edge
+func (e identifiedLabeledEdge) Label() string {
+	return e.Edge.(LabeledEdge).Label()
+}
+
+// NewIdentifiedEdge returns e with the given ID
+// If e is a LabeledEdge, so is the result.
+func NewIdentifiedEdge(e goraph.Edge, id string) IdentifiedEdge {
+	ie := identifiedEdge{e, id}
+	if _, ok := e.(LabeledEdge); ok {
+		return identifiedLabeledEdge{ie}
+	}
+	return ie
+}
+
+// Multigraph is a Graph that can have several edges going from a node to another, told apart by their IDs
+// Its edges are returned by Incoming in the order they were added, so that ties between parallel edges are broken in favour of the first one.
+type Multigraph struct {
+	ids      []goraph.ID
+	nodes    map[string]goraph.Node
+	incoming map[string][]goraph.Edge
+	edges    map[string]IdentifiedEdge
+	next     int // the number tried first for the next automatic ID
+}
+
+// NewMultigraph returns an empty Multigraph
+func NewMultigraph() *Multigraph {
+	return &Multigraph{
+		nodes:    make(map[string]goraph.Node),
+		incoming: make(map[string][]goraph.Edge),
+		edges:    make(map[string]IdentifiedEdge),
+	}
+}
+
+// AddNode adds a node with the given ID, if there isn't one already, and returns it
+func (m *Multigraph) AddNode(id string) goraph.Node {
+	if node, ok := m.nodes[id]; ok {
+		return node
+	}
+	node := goraph.NewNode(id)
+	m.ids = append(m.ids, node.ID())
+	m.nodes[id] = node
+	return node
+}
+
+// AddEdge adds an edge going from source to target with the given weight, adding its endpoints if need be
+// Its ID is that of e if it is an IdentifiedEdge, or else the next number, counting from 0, that isn't already the ID of an edge, and must be unique.
+// Its label is kept if it is a LabeledEdge. It returns the edge added.
+func (m *Multigraph) AddEdge(e goraph.Edge) (IdentifiedEdge, error) {
+	var id string
+	if ie, ok := e.(IdentifiedEdge); ok {
+		id = ie.EdgeID()
+	} else {
+		for {
+			id = strconv.Itoa(m.next)
+			m.next++
+			if _, ok := m.edges[id]; !ok {
+				break
+			}
+		}
+	}
+	if _, ok := m.edges[id]; ok {
+		return nil, fmt.Errorf("AddEdge: there is already an edge with ID %s", id)
+	}
+
+	source, target := m.AddNode(e.Source().ID().String()), m.AddNode(e.Target().ID().String())
+	var added goraph.Edge = goraph.NewEdge(source, target, e.Weight())
+	if le, ok := e.(LabeledEdge); ok {
+		added = NewLabeledEdge(source, target, e.Weight(), le.Label())
+	}
+	ie := NewIdentifiedEdge(added, id)
+	m.edges[id] = ie
+	m.incoming[target.ID().String()] = append(m.incoming[target.ID().String()], ie)
+	return ie, nil
+}
+
+// Edge returns the edge with the given ID
+func (m *Multigraph) Edge(id string) (IdentifiedEdge, bool) {
+	e, ok := m.edges[id]
+	return e, ok
+}
+
+// Nodes returns the IDs of every node, in the order they were added
+func (m *Multigraph) Nodes() []goraph.ID {
+	return append([]goraph.ID(nil), m.ids...)
+}
+
+// Incoming returns the edges going into the node with the given ID, as IdentifiedEdges
+func (m *Multigraph) Incoming(id goraph.ID) ([]goraph.Edge, error) {
+	if _, ok := m.nodes[id.String()]; !ok {
+		return nil, fmt.Errorf("Incoming: node %s isn't in the graph", id.String())
+	}
+	return m.incoming[id.String()], nil
+}
@@ -0,0 +1,252 @@
+package msa
+
+import (
+	"fmt"
+	"github.com/gyuho/goraph"
+	"math"
+	"strings"
+	"testing"
+)
+
+// Test that the arborescence of a multigraph says which of the parallel edges were selected
+func TestSolveGraph_Multigraph(t *testing.T) {
+	for _, graphID := range testGraphIDs() {
+		g := loadGraph(t, graphID)
+		edges, err := GetEdges(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Every edge gets two parallel copies: a heavier one, and one tying with it but added after it
+		m := NewMultigraph()
+		for id := range g.GetNodes() {
+			m.AddNode(id.String())
+		}
+		for k, e := range edges {
+			for _, parallel := range []struct {
+				suffix string
+				shift  float64
+			}{{"heavy", 1}, {"first", 0}, {"tie", 0}} {
+				id := fmt.Sprintf("%d-%s", k, parallel.suffix)
+				if _, err := m.AddEdge(NewIdentifiedEdge(goraph.NewEdge(e.Source(), e.Target(), e.Weight()+parallel.shift), id)); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+
+		for root := range g.GetNodes() {
+			for _, test := range []struct {
+				objective Objective
+				suffix    string
+				shift     float64
+			}{{Minimize, "first", 0}, {Maximize, "heavy", 1}} {
+				expected, expectedErr := Solve(g, root, WithObjective(test.objective))
+				for _, alg := range []Algorithm{Naive, Tarjan} {
+					arb, err := SolveGraph(m, root, WithObjective(test.objective), WithAlgorithm(alg))
+					if (err == nil) != (expectedErr == nil) {
+						t.Fatalf("%s rooted at %s: got error %v, expected %v", graphID, root, err, expectedErr)
+					}
+					if err != nil {
+						continue
+					}
+					if weight := expected.Weight + test.shift*float64(len(arb.Edges)); arb.Weight != weight {
+						t.Errorf("%s rooted at %s (%v, %v): got weight %v, expected %v", graphID, root, test.objective, alg, arb.Weight, weight)
+					}
+					for _, e := range arb.Edges {
+						ie, ok := e.(IdentifiedEdge)
+						if !ok {
+							t.Fatalf("%s rooted at %s: edge %s has no ID", graphID, root, e)
+						}
+						if !strings.HasSuffix(ie.EdgeID(), "-"+test.suffix) {
+							t.Errorf("%s rooted at %s (%v, %v): edge %s was selected, expected its %s copy", graphID, root, test.objective, alg, ie.EdgeID(), test.suffix)
+						}
+						if added, _ := m.Edge(ie.EdgeID()); added != ie {
+							t.Errorf("%s rooted at %s: edge %s isn't the one added", graphID, root, ie.EdgeID())
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestMultigraph_AddEdge(t *testing.T) {
+	m := NewMultigraph()
+	a, b := goraph.NewNode("A"), goraph.NewNode("B")
+	first, err := m.AddEdge(goraph.NewEdge(a, b, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := m.AddEdge(NewLabeledEdge(a, b, 2, "label"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.EdgeID() != "0" || second.EdgeID() != "1" {
+		t.Errorf("Expected IDs 0 and 1, got %s and %s", first.EdgeID(), second.EdgeID())
+	}
+	if le, ok := second.(LabeledEdge); !ok || le.Label() != "label" {
+		t.Errorf("The label of %s was lost", second)
+	}
+	if _, ok := first.(LabeledEdge); ok {
+		t.Errorf("%s is labeled", first)
+	}
+	if _, err := m.AddEdge(NewIdentifiedEdge(goraph.NewEdge(b, a, 3), "1")); err == nil {
+		t.Error("Expected an error for a duplicate ID")
+	}
+	if n := len(m.Nodes()); n != 2 {
+		t.Errorf("Expected 2 nodes, got %d", n)
+	}
+	if edges, _ := m.Incoming(goraph.StringID("B")); len(edges) != 2 {
+		t.Errorf("Expected 2 edges going into B, got %d", len(edges))
+	}
+
+	// Automatic IDs skip those already taken
+	if _, err := m.AddEdge(NewIdentifiedEdge(goraph.NewEdge(b, a, 3), "3")); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"2", "4"} {
+		e, err := m.AddEdge(goraph.NewEdge(b, a, 4))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.EdgeID() != expected {
+			t.Errorf("Expected ID %s, got %s", expected, e.EdgeID())
+		}
+	}
+	if _, err := m.Incoming(goraph.StringID("C")); err == nil {
+		t.Error("Expected an error for an unknown node")
+	}
+
+	arb, err := SolveGraph(m, goraph.StringID("A"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := arb.Parent[goraph.StringID("B")]; e != first {
+		t.Errorf("Expected %s to be selected, got %s", first, e)
+	}
+}
+
+// Test that the Graph variants of the other solvers tell parallel edges apart
+func TestMultigraph_Variants(t *testing.T) {
+	m := NewMultigraph()
+	a, b, c := goraph.NewNode("A"), goraph.NewNode("B"), goraph.NewNode("C")
+	light, err := m.AddEdge(goraph.NewEdge(a, b, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	heavy, err := m.AddEdge(goraph.NewEdge(a, b, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := m.AddEdge(goraph.NewEdge(a, c, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := goraph.StringID("A")
+
+	count, err := CountArborescencesGraph(m, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count.Int64() != 2 {
+		t.Errorf("Expected 2 arborescences, got %v", count)
+	}
+
+	logZ, err := LogPartitionGraph(m, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := math.Log(math.Exp(-1)+math.Exp(-2)) - 3; math.Abs(logZ-expected) > 1e-9 {
+		t.Errorf("Expected log-partition %v, got %v", expected, logZ)
+	}
+
+	marginals, err := MarginalsGraph(m, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for e, expected := range map[goraph.Edge]float64{
+		light: math.Exp(-1) / (math.Exp(-1) + math.Exp(-2)),
+		heavy: math.Exp(-2) / (math.Exp(-1) + math.Exp(-2)),
+		other: 1,
+	} {
+		if math.Abs(marginals[e]-expected) > 1e-9 {
+			t.Errorf("Expected marginal %v for %s, got %v", expected, e, marginals[e])
+		}
+	}
+
+	arb, err := SolveAllRootsGraph(m, WithObjective(Maximize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if arb.Root.String() != "A" || arb.Parent[goraph.StringID("B")] != heavy {
+		t.Errorf("Expected the arborescence rooted at A through %s, got %v", heavy, arb.Edges)
+	}
+	if err := VerifyGraph(m, root, arb); err != nil {
+		t.Errorf("Unexpected error for the heavier parallel edge: %v", err)
+	}
+	mislabeled := NewIdentifiedEdge(goraph.NewEdge(a, b, 2), light.EdgeID())
+	wrong := &Arborescence{
+		Root:   root,
+		Parent: map[goraph.ID]goraph.Edge{goraph.StringID("B"): mislabeled, goraph.StringID("C"): other},
+		Weight: 5,
+		Edges:  []goraph.Edge{mislabeled, other},
+	}
+	if err := VerifyGraph(m, root, wrong); err == nil {
+		t.Error("Expected an error for an edge whose weight isn't that of the edge with the same ID")
+	}
+
+	branching, err := MSBGraph(m, WithRootCost(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branching.Weight != 4 || len(branching.Roots) != 1 || branching.Parent[goraph.StringID("B")] != light {
+		t.Errorf("Expected a single tree through %s, got roots %v and edges %v", light, branching.Roots, branching.Edges)
+	}
+}
+
+// Test that completing a branching to meet the maximum number of roots keeps the best of parallel edges, and returns it
+func TestMSBGraph_ParallelMaxRoots(t *testing.T) {
+	for _, objective := range []Objective{Minimize, Maximize} {
+		// Every edge has a better copy weighing 5, and a worse one weighing 7, their sign making a single tree worse than two
+		sign := 1.0
+		if objective == Maximize {
+			sign = -1
+		}
+		m := NewMultigraph()
+		a, b, c := goraph.NewNode("a"), goraph.NewNode("b"), goraph.NewNode("c")
+		for _, e := range []struct {
+			source, target goraph.Node
+			weight         float64
+			id             string
+		}{{a, b, 5, "ab-best"}, {a, b, 7, "ab-worst"}, {b, c, 5, "bc-best"}, {b, c, 7, "bc-worst"}} {
+			if _, err := m.AddEdge(NewIdentifiedEdge(goraph.NewEdge(e.source, e.target, sign*e.weight), e.id)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		labeled := LabeledAdjacency{
+			"a": {},
+			"b": {"a": {"best": sign * 5, "worst": sign * 7}},
+			"c": {"b": {"best": sign * 5, "worst": sign * 7}},
+		}
+
+		for _, alg := range []Algorithm{Naive, Tarjan} {
+			for _, g := range []Graph{m, labeled} {
+				branching, err := MSBGraph(g, WithObjective(objective), WithAlgorithm(alg), WithMaxRoots(2))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(branching.Roots) != 2 || branching.Weight != sign*5 || len(branching.Edges) != 1 {
+					t.Errorf("%T (%v, %v): got roots %v, weight %v and edges %v, expected 2 roots and weight %v", g, objective, alg, branching.Roots, branching.Weight, branching.Edges, sign*5)
+					continue
+				}
+				e := branching.Edges[0]
+				if ie, ok := e.(IdentifiedEdge); ok && !strings.HasSuffix(ie.EdgeID(), "-best") {
+					t.Errorf("%T (%v, %v): got edge %s, expected its best copy", g, objective, alg, ie.EdgeID())
+				}
+				if le, ok := e.(LabeledEdge); ok && le.Label() != "best" {
+					t.Errorf("%T (%v, %v): got label %s, expected best", g, objective, alg, le.Label())
+				}
+			}
+		}
+	}
+}
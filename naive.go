@@ -0,0 +1,130 @@
+package msa
+
+// This file implements the naive version of Chu–Liu/Edmonds' algorithm on a digraph, in O(VE)
+// Every node picks its lightest incoming arc, and if they make cycles, each of them is contracted into a single node
+// in a new, smaller, level of arcs, which is solved recursively before being expanded back.
+
+// levelArc is an arc of a level of the naive algorithm
+type levelArc struct {
+	from, to int
+	weight   float64
+	prev     int // index of the arc of the previous level it comes from
+}
+
+// naive computes the Minimum Spanning Arborescence of d rooted at root
+// It returns the index of the arc going into every node (-1 for the root), or false if no spanning arborescence exists
+func (d *digraph) naive(root int) ([]int, bool) {
+	arcs := make([]levelArc, 0, len(d.arcs))
+	for i, a := range d.arcs {
+		if a.to != root {
+			arcs = append(arcs, levelArc{from: a.from, to: a.to, weight: a.weight, prev: i})
+		}
+	}
+	in, ok := solveLevel(len(d.nodes), root, arcs)
+	if !ok {
+		return nil, false
+	}
+	for v, a := range in {
+		if a >= 0 {
+			in[v] = arcs[a].prev
+		}
+	}
+	return in, true
+}
+
+// solveLevel solves a level of n nodes, none of its arcs going into root or from a node to itself
+// It returns the index of the arc going into every node (-1 for the root), or false if no spanning arborescence exists
+func solveLevel(n int, root int, arcs []levelArc) ([]int, bool) {
+	// Select the lightest arc going into every node, ties being broken by index
+	lightest := make([]int, n)
+	for v := range lightest {
+		lightest[v] = -1
+	}
+	for i, a := range arcs {
+		if l := lightest[a.to]; l < 0 || a.weight < arcs[l].weight {
+			lightest[a.to] = i
+		}
+	}
+	for v, l := range lightest {
+		if v != root && l < 0 {
+			return nil, false
+		}
+	}
+
+	// Look for cycles by following the selected arcs backwards, numbering the nodes of the next level along the way
+	// Nodes of a same cycle share their number
+	const (
+		unvisited = -1
+		attached  = -2
+	)
+	walk := make([]int, n) // the walk that visited every node, or attached once it is known to lead to the root
+	for v := range walk {
+		walk[v] = unvisited
+	}
+	walk[root] = attached
+	next := make([]int, n) // number of every node in the next level
+	for v := range next {
+		next[v] = -1
+	}
+	inCycle := make([]bool, n)
+	m := 0
+	cycles := false
+	for s := 0; s < n; s++ {
+		v := s
+		for walk[v] == unvisited {
+			walk[v] = s
+			v = arcs[lightest[v]].from
+		}
+		if walk[v] == s {
+			// The walk looped back on itself, making a cycle
+			cycles = true
+			for u := v; !inCycle[u]; u = arcs[lightest[u]].from {
+				inCycle[u] = true
+				next[u] = m
+			}
+			m++
+		}
+		for v := s; walk[v] != attached; v = arcs[lightest[v]].from {
+			walk[v] = attached
+		}
+	}
+	if !cycles {
+		lightest[root] = -1
+		return lightest, true
+	}
+	for v := range next {
+		if next[v] < 0 {
+			next[v] = m
+			m++
+		}
+	}
+
+	// Build the next level, the arcs going into a cycle being reduced by the weight of the cycle arc they would replace
+	var nextArcs []levelArc
+	for i, a := range arcs {
+		from, to := next[a.from], next[a.to]
+		if from == to {
+			continue
+		}
+		weight := a.weight
+		if inCycle[a.to] {
+			weight -= arcs[lightest[a.to]].weight
+		}
+		nextArcs = append(nextArcs, levelArc{from: from, to: to, weight: weight, prev: i})
+	}
+	nextIn, ok := solveLevel(m, next[root], nextArcs)
+	if !ok {
+		return nil, false
+	}
+
+	// Expand: the arcs selected in the next level are kept, and the cycle arcs of every node they don't go into
+	in := lightest
+	for _, a := range nextIn {
+		if a >= 0 {
+			prev := nextArcs[a].prev
+			in[arcs[prev].to] = prev
+		}
+	}
+	in[root] = -1
+	return in, true
+}
@@ -0,0 +1,51 @@
+package msa
+
+import (
+	"github.com/gyuho/goraph"
+	"testing"
+)
+
+func TestSolve_Maximize(t *testing.T) {
+	tests := []struct {
+		graphID string
+		root    string
+		weight  float64
+	}{
+		{"graph_17", "A", 28},
+		{"graph_17", "B", 23},
+		{"graph_17", "C", 23},
+		{"graph_17", "D", 19},
+		{"graph_00", "S", 429},
+		{"graph_12", "S", 29},
+	}
+
+	for _, test := range tests {
+		g := loadGraph(t, test.graphID)
+		for _, alg := range []Algorithm{Naive, Tarjan} {
+			arb, err := Solve(g, goraph.StringID(test.root), WithAlgorithm(alg), WithObjective(Maximize))
+			if err != nil {
+				t.Errorf("%s rooted at %s (%v): unexpected error: %v", test.graphID, test.root, alg, err)
+				continue
+			}
+			if arb.Weight != test.weight {
+				t.Errorf("%s rooted at %s (%v): expected weight %v, got %v", test.graphID, test.root, alg, test.weight, arb.Weight)
+			}
+
+			// The edges must carry their original weight
+			var total float64
+			for _, e := range arb.Edges {
+				w, err := g.GetWeight(e.Source().ID(), e.Target().ID())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if w != e.Weight() {
+					t.Errorf("%s rooted at %s (%v): edge %s should have weight %v", test.graphID, test.root, alg, e, w)
+				}
+				total += w
+			}
+			if total != test.weight {
+				t.Errorf("%s rooted at %s (%v): edges sum to %v, expected %v", test.graphID, test.root, alg, total, test.weight)
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package msa
+
+import (
+	"github.com/gyuho/goraph"
+	"runtime"
+)
+
+// Algorithm selects the implementation of Chu–Liu/Edmonds' algorithm
+type Algorithm int
+
+const (
+	// Naive contracts the cycles by building a new graph for each of them, it is slow but simple
+	Naive Algorithm = iota
+
+	// Tarjan contracts the cycles using mergeable heaps and union-find, in O(E log V)
+	Tarjan
+)
+
+// String returns the name of the algorithm
+func (a Algorithm) String() string {
+	switch a {
+	case Naive:
+		return "Naive"
+	case Tarjan:
+		return "Tarjan"
+	default:
+		return "Algorithm(?)"
+	}
+}
+
+// Objective selects whether the arborescence sought is of minimum or maximum weight
+type Objective int
+
+const (
+	// Minimize looks for the spanning arborescence of minimum weight, it is the default
+	Minimize Objective = iota
+
+	// Maximize looks for the spanning arborescence of maximum weight, as used in dependency parsing
+	Maximize
+)
+
+// String returns the name of the objective
+func (o Objective) String() string {
+	switch o {
+	case Minimize:
+		return "Minimize"
+	case Maximize:
+		return "Maximize"
+	default:
+		return "Objective(?)"
+	}
+}
+
+// config holds the configuration set by Options
+type config struct {
+	algorithm Algorithm
+	objective Objective
+	rootCost  float64 // only used by MSB
+	maxRoots  int     // only used by MSB, 0 meaning no limit
+
+	// Edges that must, or must not, be part of the arborescence
+	required  map[edgeID]goraph.Edge
+	forbidden map[edgeID]goraph.Edge
+
+	duals bool // whether Solve returns the dual variables
+
+	workers int // size of the worker pool of SolveBatch and SolveStream, 0 meaning GOMAXPROCS
+}
+
+// sign returns the factor to apply to weights so that the objective becomes a minimization
+func (c *config) sign() float64 {
+	if c.objective == Maximize {
+		return -1
+	}
+	return 1
+}
+
+// poolSize returns the number of workers to use
+func (c *config) poolSize() int {
+	if c.workers > 0 {
+		return c.workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// newConfig returns the configuration resulting of applying opts to the default one
+func newConfig(opts []Option) *config {
+	c := &config{
+		algorithm: Naive,
+		objective: Minimize,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Option configures Solve
+type Option func(*config)
+
+// WithAlgorithm selects the algorithm used, Naive being the default
+func WithAlgorithm(a Algorithm) Option {
+	return func(c *config) {
+		c.algorithm = a
+	}
+}
+
+// WithObjective selects whether to minimize or maximize the weight of the arborescence, Minimize being the default
+// Maximizing is done by negating the weights, so both share the same contraction machinery
+func WithObjective(o Objective) Option {
+	return func(c *config) {
+		c.objective = o
+	}
+}
+
+// WithRootCost sets the cost of every root of a branching found by MSB, 0 being the default
+// When minimizing it is added to the weight of the branching, when maximizing it is subtracted from it
+// The higher it is, the fewer trees the branching has
+func WithRootCost(cost float64) Option {
+	return func(c *config) {
+		c.rootCost = cost
+	}
+}
+
+// WithMaxRoots limits the number of roots of a branching found by MSB, 0 meaning no limit
+func WithMaxRoots(n int) Option {
+	return func(c *config) {
+		c.maxRoots = n
+	}
+}
+
+// WithRequired requires the given edges to be part of the arborescence, only their source and target being considered
+// Solve returns a *ConstraintError if they contradict each other, for instance when two of them go to the same node or when they make a cycle
+func WithRequired(edges ...goraph.Edge) Option {
+	return func(c *config) {
+		if c.required == nil {
+			c.required = make(map[edgeID]goraph.Edge, len(edges))
+		}
+		for _, e := range edges {
+			c.required[edgeKey(e.Source().ID(), e.Target().ID())] = e
+		}
+	}
+}
+
+// WithForbidden forbids the given edges from being part of the arborescence, only their source and target being considered
+func WithForbidden(edges ...goraph.Edge) Option {
+	return func(c *config) {
+		if c.forbidden == nil {
+			c.forbidden = make(map[edgeID]goraph.Edge, len(edges))
+		}
+		for _, e := range edges {
+			c.forbidden[edgeKey(e.Source().ID(), e.Target().ID())] = e
+		}
+	}
+}
+
+// WithDuals makes Solve return the dual variables of the arborescence, with which Verify can certify that it is optimal
+func WithDuals() Option {
+	return func(c *config) {
+		c.duals = true
+	}
+}
+
+// WithWorkers sets the number of problems SolveBatch and SolveStream solve concurrently, GOMAXPROCS being the default
+func WithWorkers(n int) Option {
+	return func(c *config) {
+		c.workers = n
+	}
+}
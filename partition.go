@@ -0,0 +1,198 @@
+package msa
+
+import (
+	"fmt"
+	"github.com/gyuho/goraph"
+	"math"
+	"math/big"
+)
+
+// This file implements Tutte's directed matrix-tree theorem: the sum over the arborescences rooted at root of the product of their edge scores
+// is the determinant of the Laplacian of the graph, deprived of the root's row and column.
+// See Koo, Globerson, Carreras, Collins, "Structured prediction models via the matrix-tree theorem", EMNLP 2007
+
+// LogPartition returns the logarithm of the sum, over every spanning arborescence of g rooted at root, of the product of their edge scores.
+// The score of an edge of weight w is exp(-w), or exp(w) with WithObjective(Maximize), so that the arborescence found by Solve with the same options is the most likely one.
+// It is computed in log-space, so that large weights don't overflow.
+// Constraints set with WithRequired and WithForbidden restrict the arborescences summed over.
+// If some nodes can't be reached from root, the returned error is an *InfeasibleError listing them.
+func LogPartition(g goraph.Graph, root goraph.ID, opts ...Option) (float64, error) {
+	z, err := logPartition(Goraph(g), root, newConfig(opts))
+	switch err.(type) {
+	case nil, *InfeasibleError, *ConstraintError:
+		return z, err
+	default:
+		return 0, fmt.Errorf("LogPartition: %v", err)
+	}
+}
+
+// LogPartitionGraph is like LogPartition, but works on any Graph
+// Several edges going from a node to another are summed over separately, as are the alternatives of a LabeledEdge.
+func LogPartitionGraph(g Graph, root goraph.ID, opts ...Option) (float64, error) {
+	z, err := logPartition(g, root, newConfig(opts))
+	switch err.(type) {
+	case nil, *InfeasibleError, *ConstraintError:
+		return z, err
+	default:
+		return 0, fmt.Errorf("LogPartitionGraph: %v", err)
+	}
+}
+
+// logPartition implements LogPartition and LogPartitionGraph
+func logPartition(g Graph, root goraph.ID, c *config) (float64, error) {
+	d, r, err := newConstrainedDigraph(g, root, c)
+	if err != nil {
+		return 0, err
+	}
+
+	l, shift := d.laplacian(r, c.sign())
+	lu, ok := newLUDecomposition(l)
+	if !ok {
+		return 0, fmt.Errorf("the Laplacian is singular")
+	}
+	logDet, sign := lu.logDet()
+	if sign <= 0 {
+		return 0, fmt.Errorf("the determinant of the Laplacian isn't positive, weights may be too far apart")
+	}
+	return logDet + shift, nil
+}
+
+// CountArborescences returns the exact number of spanning arborescences of g rooted at root, ignoring weights
+func CountArborescences(g goraph.Graph, root goraph.ID) (*big.Int, error) {
+	n, err := countArborescences(Goraph(g), root)
+	switch err.(type) {
+	case nil, *InfeasibleError, *ConstraintError:
+		return n, err
+	default:
+		return nil, fmt.Errorf("CountArborescences: %v", err)
+	}
+}
+
+// CountArborescencesGraph is like CountArborescences, but works on any Graph
+// Arborescences made of different edges going from a node to another are counted separately.
+func CountArborescencesGraph(g Graph, root goraph.ID) (*big.Int, error) {
+	n, err := countArborescences(g, root)
+	switch err.(type) {
+	case nil, *InfeasibleError, *ConstraintError:
+		return n, err
+	default:
+		return nil, fmt.Errorf("CountArborescencesGraph: %v", err)
+	}
+}
+
+// countArborescences implements CountArborescences and CountArborescencesGraph
+func countArborescences(g Graph, root goraph.ID) (*big.Int, error) {
+	d, err := newDigraph(g)
+	if err != nil {
+		return nil, fmt.Errorf("error while converting graph: %v", err)
+	}
+	r, ok := d.index[root.String()]
+	if !ok {
+		return nil, fmt.Errorf("root %s isn't in the graph", root.String())
+	}
+
+	// Build the integer Laplacian
+	row := d.rows(r)
+	n := len(d.nodes) - 1
+	l := make([][]*big.Int, n)
+	for i := range l {
+		l[i] = make([]*big.Int, n)
+		for j := range l[i] {
+			l[i][j] = new(big.Int)
+		}
+	}
+	one := big.NewInt(1)
+	for _, a := range d.arcs {
+		if a.to == r {
+			continue
+		}
+		v := row[a.to]
+		l[v][v].Add(l[v][v], one)
+		if a.from != r {
+			l[row[a.from]][v].Sub(l[row[a.from]][v], one)
+		}
+	}
+
+	return bareissDet(l), nil
+}
+
+// newConstrainedDigraph converts g, applies the constraints and checks that every node can be reached from root
+// It returns the digraph along with the index of the root
+func newConstrainedDigraph(g Graph, root goraph.ID, c *config) (*digraph, int, error) {
+	d, err := newDigraph(g)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error while converting graph: %v", err)
+	}
+	r, ok := d.index[root.String()]
+	if !ok {
+		return nil, 0, fmt.Errorf("root %s isn't in the graph", root.String())
+	}
+	if err := c.checkConstraints(g, root); err != nil {
+		return nil, 0, err
+	}
+	d.constrain(c)
+	if unreachable := d.unreachable(r); len(unreachable) != 0 {
+		return nil, 0, &InfeasibleError{Root: root, Unreachable: unreachable}
+	}
+	return d, r, nil
+}
+
+// rows returns the row of every node in the Laplacian, -1 for the root
+func (d *digraph) rows(root int) []int {
+	row := make([]int, len(d.nodes))
+	i := 0
+	for v := range d.nodes {
+		if v == root {
+			row[v] = -1
+			continue
+		}
+		row[v] = i
+		i++
+	}
+	return row
+}
+
+// maxLogScores returns the largest log-score, -sign*weight, of the arcs going into every node
+func (d *digraph) maxLogScores(sign float64) []float64 {
+	max := make([]float64, len(d.nodes))
+	for i := range max {
+		max[i] = math.Inf(-1)
+	}
+	for _, a := range d.arcs {
+		max[a.to] = math.Max(max[a.to], -sign*a.weight)
+	}
+	return max
+}
+
+// laplacian returns the Laplacian of d without the root's row and column, in which entry (u, v) relates to the arcs going from u to v
+// Arc scores are exp(-sign*weight), each column being scaled by the largest score going into it to avoid overflows,
+// the logarithm of the product of these factors being returned along with the matrix
+func (d *digraph) laplacian(root int, sign float64) ([][]float64, float64) {
+	row := d.rows(root)
+	max := d.maxLogScores(sign)
+
+	n := len(d.nodes) - 1
+	l := make([][]float64, n)
+	for i := range l {
+		l[i] = make([]float64, n)
+	}
+	for _, a := range d.arcs {
+		if a.to == root {
+			continue
+		}
+		v := row[a.to]
+		score := math.Exp(-sign*a.weight - max[a.to])
+		l[v][v] += score
+		if a.from != root {
+			l[row[a.from]][v] -= score
+		}
+	}
+
+	var shift float64
+	for v, m := range max {
+		if v != root {
+			shift += m
+		}
+	}
+	return l, shift
+}
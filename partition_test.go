@@ -0,0 +1,104 @@
+package msa
+
+import (
+	"github.com/gyuho/goraph"
+	"math"
+	"testing"
+)
+
+// logSumExp returns log(sum(exp(x))) of the given values
+func logSumExp(xs []float64) float64 {
+	max := math.Inf(-1)
+	for _, x := range xs {
+		max = math.Max(max, x)
+	}
+	var sum float64
+	for _, x := range xs {
+		sum += math.Exp(x - max)
+	}
+	return max + math.Log(sum)
+}
+
+// Test LogPartition against the enumeration of every arborescence by KBest
+func TestLogPartition(t *testing.T) {
+	tests := []struct {
+		graphID string
+		root    string
+		count   int64
+	}{
+		{"graph_17", "D", 3},
+		{"graph_12", "B", 9},
+		{"graph_08", "A", 70},
+		{"graph_00", "S", 1543},
+	}
+
+	for _, test := range tests {
+		g := loadGraph(t, test.graphID)
+		root := goraph.StringID(test.root)
+
+		count, err := CountArborescences(g, root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !count.IsInt64() || count.Int64() != test.count {
+			t.Errorf("%s rooted at %s: expected %d arborescences, counted %v", test.graphID, test.root, test.count, count)
+		}
+
+		for _, obj := range []Objective{Minimize, Maximize} {
+			all, err := KBest(g, root, int(test.count)+1, WithAlgorithm(Tarjan), WithObjective(obj))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(all) != int(test.count) {
+				t.Fatalf("%s rooted at %s: KBest enumerated %d arborescences, expected %d", test.graphID, test.root, len(all), test.count)
+			}
+			logScores := make([]float64, len(all))
+			for i, arb := range all {
+				logScores[i] = arb.Weight
+				if obj == Minimize {
+					logScores[i] = -arb.Weight
+				}
+			}
+
+			logZ, err := LogPartition(g, root, WithObjective(obj))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected := logSumExp(logScores); math.Abs(logZ-expected) > 1e-9*math.Max(1, math.Abs(expected)) {
+				t.Errorf("%s rooted at %s (%v): expected log-partition %v, got %v", test.graphID, test.root, obj, expected, logZ)
+			}
+		}
+	}
+}
+
+func TestLogPartition_LargeWeights(t *testing.T) {
+	// Scores of exp(-15000) underflow, but their logarithm doesn't
+	g := newTestGraph(t, map[string]float64{"A B": 6000, "B C": 10000, "B D": 12000, "C B": 10000, "D A": 1000, "D C": 8000})
+	logZ, err := LogPartition(g, goraph.StringID("D"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := logSumExp([]float64{-15000, -17000, -19000}); math.Abs(logZ-expected) > 1e-9 {
+		t.Errorf("Expected log-partition %v, got %v", expected, logZ)
+	}
+}
+
+func TestCountArborescences_Infeasible(t *testing.T) {
+	count, err := CountArborescences(loadGraph(t, "graph_05"), goraph.StringID("A"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count.Sign() != 0 {
+		t.Errorf("Expected no arborescence, counted %v", count)
+	}
+	if _, err := LogPartition(loadGraph(t, "graph_05"), goraph.StringID("A")); err == nil {
+		t.Error("Expected an error for an infeasible graph")
+	} else if _, ok := err.(*InfeasibleError); !ok {
+		t.Errorf("Expected an *InfeasibleError, got %T: %v", err, err)
+	}
+	if _, err := LogPartitionGraph(Goraph(loadGraph(t, "graph_05")), goraph.StringID("A")); err == nil {
+		t.Error("Expected an error for an infeasible graph")
+	} else if _, ok := err.(*InfeasibleError); !ok {
+		t.Errorf("Expected an *InfeasibleError, got %T: %v", err, err)
+	}
+}
@@ -0,0 +1,117 @@
+package msa
+
+import (
+	"fmt"
+	"github.com/gyuho/goraph"
+	"math"
+	"math/rand"
+)
+
+// Sample draws a random spanning arborescence of g rooted at root, with a probability proportional to the product of its edge scores, as defined by LogPartition.
+// It uses Wilson's algorithm: from every node not yet in the arborescence, a random walk follows incoming edges backwards, chosen according to their score,
+// until it reaches the arborescence, its loops being erased. See D. B. Wilson, "Generating random spanning trees more quickly than the cover time", STOC 1996.
+// The randomness comes from src, so that results can be reproduced. g isn't modified.
+// If some nodes can't be reached from root, the returned error is an *InfeasibleError listing them.
+func Sample(g goraph.Graph, root goraph.ID, src rand.Source, opts ...Option) (*Arborescence, error) {
+	arb, err := sample(Goraph(g), root, src, false, opts)
+	switch err.(type) {
+	case nil, *InfeasibleError, *ConstraintError:
+		return arb, err
+	default:
+		return nil, fmt.Errorf("Sample: %v", err)
+	}
+}
+
+// SampleGraph is like Sample, but works on any Graph
+// Every one of several edges going from a node to another is drawn according to its own score.
+func SampleGraph(g Graph, root goraph.ID, src rand.Source, opts ...Option) (*Arborescence, error) {
+	arb, err := sample(g, root, src, false, opts)
+	switch err.(type) {
+	case nil, *InfeasibleError, *ConstraintError:
+		return arb, err
+	default:
+		return nil, fmt.Errorf("SampleGraph: %v", err)
+	}
+}
+
+// SampleUniform draws a spanning arborescence of g rooted at root uniformly at random, ignoring weights, like Sample does otherwise.
+func SampleUniform(g goraph.Graph, root goraph.ID, src rand.Source, opts ...Option) (*Arborescence, error) {
+	arb, err := sample(Goraph(g), root, src, true, opts)
+	switch err.(type) {
+	case nil, *InfeasibleError, *ConstraintError:
+		return arb, err
+	default:
+		return nil, fmt.Errorf("SampleUniform: %v", err)
+	}
+}
+
+// SampleUniformGraph is like SampleUniform, but works on any Graph
+func SampleUniformGraph(g Graph, root goraph.ID, src rand.Source, opts ...Option) (*Arborescence, error) {
+	arb, err := sample(g, root, src, true, opts)
+	switch err.(type) {
+	case nil, *InfeasibleError, *ConstraintError:
+		return arb, err
+	default:
+		return nil, fmt.Errorf("SampleUniformGraph: %v", err)
+	}
+}
+
+// sample implements Sample, SampleUniform and their Graph variants
+func sample(g Graph, root goraph.ID, src rand.Source, uniform bool, opts []Option) (*Arborescence, error) {
+	c := newConfig(opts)
+	d, r, err := newConstrainedDigraph(g, root, c)
+	if err != nil {
+		return nil, err
+	}
+	rng := rand.New(src)
+
+	// The incoming arcs of every node, along with their cumulated scores
+	max := d.maxLogScores(c.sign())
+	incoming := make([][]int, len(d.nodes))
+	cumulated := make([][]float64, len(d.nodes))
+	for i, a := range d.arcs {
+		score := 1.0
+		if !uniform {
+			score = math.Exp(-c.sign()*a.weight - max[a.to])
+		}
+		total := score
+		if k := len(cumulated[a.to]); k != 0 {
+			total += cumulated[a.to][k-1]
+		}
+		incoming[a.to] = append(incoming[a.to], i)
+		cumulated[a.to] = append(cumulated[a.to], total)
+	}
+
+	// randomArc picks an arc going into v according to the scores
+	randomArc := func(v int) int {
+		cum := cumulated[v]
+		x := rng.Float64() * cum[len(cum)-1]
+		lo, hi := 0, len(cum)-1
+		for lo < hi {
+			mid := (lo + hi) / 2
+			if cum[mid] > x {
+				hi = mid
+			} else {
+				lo = mid + 1
+			}
+		}
+		return incoming[v][lo]
+	}
+
+	inTree := make([]bool, len(d.nodes))
+	inTree[r] = true
+	next := make([]int, len(d.nodes))
+	next[r] = -1
+	for v := range d.nodes {
+		// Walk until reaching the arborescence, only the last arc chosen from every node being remembered, which erases the loops
+		for u := v; !inTree[u]; u = d.arcs[next[u]].from {
+			next[u] = randomArc(u)
+		}
+		// Add the loop-erased path to the arborescence
+		for u := v; !inTree[u]; u = d.arcs[next[u]].from {
+			inTree[u] = true
+		}
+	}
+
+	return d.arborescence(r, next), nil
+}
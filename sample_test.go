@@ -0,0 +1,120 @@
+package msa
+
+import (
+	"github.com/gyuho/goraph"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// arborescenceKey returns a string identifying the edges of arb
+func arborescenceKey(arb *Arborescence) string {
+	edges := make([]string, len(arb.Edges))
+	for i, e := range arb.Edges {
+		edges[i] = e.Source().ID().String() + "->" + e.Target().ID().String()
+	}
+	sort.Strings(edges)
+	return strings.Join(edges, " ")
+}
+
+// Test that the frequency of every sampled arborescence matches its probability
+func TestSample(t *testing.T) {
+	const n = 20000
+	tests := []struct {
+		graphID string
+		root    string
+		uniform bool
+		obj     Objective
+	}{
+		{"graph_17", "D", false, Minimize},
+		{"graph_17", "D", false, Maximize},
+		{"graph_12", "B", true, Minimize},
+		{"graph_08", "A", true, Minimize},
+	}
+
+	for _, test := range tests {
+		g := loadGraph(t, test.graphID)
+		root := goraph.StringID(test.root)
+		logZ, err := LogPartition(g, root, WithObjective(test.obj))
+		if err != nil {
+			t.Fatal(err)
+		}
+		count, err := CountArborescences(g, root)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		src := rand.NewSource(1)
+		frequencies := make(map[string]int)
+		weights := make(map[string]float64)
+		for i := 0; i < n; i++ {
+			var arb *Arborescence
+			if test.uniform {
+				arb, err = SampleUniform(g, root, src, WithObjective(test.obj))
+			} else {
+				arb, err = Sample(g, root, src, WithObjective(test.obj))
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(arb.Parent) != g.GetNodeCount()-1 {
+				t.Fatalf("Sampled %d parents, expected %d", len(arb.Parent), g.GetNodeCount()-1)
+			}
+			key := arborescenceKey(arb)
+			frequencies[key]++
+			weights[key] = arb.Weight
+		}
+
+		for key, f := range frequencies {
+			p := 1 / float64(count.Int64())
+			if !test.uniform {
+				logScore := weights[key]
+				if test.obj == Minimize {
+					logScore = -logScore
+				}
+				p = math.Exp(logScore - logZ)
+			}
+			// Allow for 5 standard deviations
+			if tolerance := 5 * math.Sqrt(p*(1-p)/n); math.Abs(float64(f)/n-p) > tolerance {
+				t.Errorf("%s rooted at %s: %s sampled with frequency %v, expected %v", test.graphID, test.root, key, float64(f)/n, p)
+			}
+		}
+		if test.uniform && len(frequencies) != int(count.Int64()) {
+			t.Errorf("%s rooted at %s: sampled %d distinct arborescences out of %v", test.graphID, test.root, len(frequencies), count)
+		}
+	}
+}
+
+func TestSample_Reproducible(t *testing.T) {
+	g := loadGraph(t, "graph_00")
+	a, err := SampleUniform(g, goraph.StringID("S"), rand.NewSource(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := SampleUniform(g, goraph.StringID("S"), rand.NewSource(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if arborescenceKey(a) != arborescenceKey(b) {
+		t.Errorf("The same seed gave different arborescences: %s and %s", arborescenceKey(a), arborescenceKey(b))
+	}
+}
+
+func TestSample_Infeasible(t *testing.T) {
+	g := loadGraph(t, "graph_05")
+	root := goraph.StringID("A")
+	for name, sample := range map[string]func() (*Arborescence, error){
+		"Sample":             func() (*Arborescence, error) { return Sample(g, root, rand.NewSource(1)) },
+		"SampleGraph":        func() (*Arborescence, error) { return SampleGraph(Goraph(g), root, rand.NewSource(1)) },
+		"SampleUniform":      func() (*Arborescence, error) { return SampleUniform(g, root, rand.NewSource(1)) },
+		"SampleUniformGraph": func() (*Arborescence, error) { return SampleUniformGraph(Goraph(g), root, rand.NewSource(1)) },
+	} {
+		if _, err := sample(); err == nil {
+			t.Errorf("%s: expected an error for an infeasible graph", name)
+		} else if _, ok := err.(*InfeasibleError); !ok {
+			t.Errorf("%s: expected an *InfeasibleError, got %T: %v", name, err, err)
+		}
+	}
+}
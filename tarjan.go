@@ -0,0 +1,253 @@
+package msa
+
+// This file implements the efficient version of Chu–Liu/Edmonds' algorithm, in O(E log V)
+// Instead of rebuilding the graph on every contraction, the incoming edges of each (super)node are kept in a mergeable heap,
+// contracted nodes are tracked with a union-find, and the reduced weights are applied lazily on whole heaps.
+// See:
+//	- R. E. Tarjan, "Finding optimum branchings", Networks, 1977
+//	- H. N. Gabow, Z. Galil, T. Spencer, R. E. Tarjan, "Efficient algorithms for finding minimum spanning trees in undirected and directed graphs", Combinatorica, 1986
+
+// heapNode is a node of a leftist heap of arcs, keyed by their reduced weight
+type heapNode struct {
+	arc         int     // index of the arc in the digraph
+	key         float64 // reduced weight, not counting the pending deltas of the ancestors
+	delta       float64 // lazy delta to add to every key of the subtree
+	rank        int     // distance to the closest nil child
+	left, right *heapNode
+}
+
+// push applies the lazy delta of n to its key and hands it down to its children
+func (n *heapNode) push() {
+	if n.delta == 0 {
+		return
+	}
+	n.key += n.delta
+	if n.left != nil {
+		n.left.delta += n.delta
+	}
+	if n.right != nil {
+		n.right.delta += n.delta
+	}
+	n.delta = 0
+}
+
+// less orders heap nodes by key, ties being broken by arc index to keep results deterministic
+func (n *heapNode) less(m *heapNode) bool {
+	return n.key < m.key || (n.key == m.key && n.arc < m.arc)
+}
+
+// mergeHeaps merges two leftist heaps
+func mergeHeaps(a, b *heapNode) *heapNode {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	a.push()
+	b.push()
+	if b.less(a) {
+		a, b = b, a
+	}
+	a.right = mergeHeaps(a.right, b)
+	if a.left == nil || a.left.rank < a.right.rank {
+		a.left, a.right = a.right, a.left
+	}
+	a.rank = 1
+	if a.right != nil {
+		a.rank = a.right.rank + 1
+	}
+	return a
+}
+
+// popHeap removes the minimum of the heap, returning the new heap
+func popHeap(h *heapNode) *heapNode {
+	h.push()
+	return mergeHeaps(h.left, h.right)
+}
+
+// rollbackUnionFind is a union-find without path compression whose unions can be undone
+type rollbackUnionFind struct {
+	parent  []int
+	size    []int
+	history []int // the roots that got attached, in order
+}
+
+func newRollbackUnionFind(n int) *rollbackUnionFind {
+	uf := &rollbackUnionFind{
+		parent: make([]int, n),
+		size:   make([]int, n),
+	}
+	for i := range uf.parent {
+		uf.parent[i] = i
+		uf.size[i] = 1
+	}
+	return uf
+}
+
+func (uf *rollbackUnionFind) find(x int) int {
+	for uf.parent[x] != x {
+		x = uf.parent[x]
+	}
+	return x
+}
+
+// union merges the sets of a and b, returning false if they were already the same
+func (uf *rollbackUnionFind) union(a, b int) bool {
+	a, b = uf.find(a), uf.find(b)
+	if a == b {
+		return false
+	}
+	if uf.size[a] < uf.size[b] {
+		a, b = b, a
+	}
+	uf.parent[b] = a
+	uf.size[a] += uf.size[b]
+	uf.history = append(uf.history, b)
+	return true
+}
+
+// time returns a marker to be given to rollback
+func (uf *rollbackUnionFind) time() int {
+	return len(uf.history)
+}
+
+// rollback undoes every union done since the given time
+func (uf *rollbackUnionFind) rollback(t int) {
+	for len(uf.history) > t {
+		b := uf.history[len(uf.history)-1]
+		uf.history = uf.history[:len(uf.history)-1]
+		a := uf.parent[b]
+		uf.size[a] -= uf.size[b]
+		uf.parent[b] = b
+	}
+}
+
+// contraction records a contracted cycle so that it can be expanded afterwards
+type contraction struct {
+	node int   // representative of the supernode
+	time int   // union-find time before the contraction
+	arcs []int // arcs of the cycle
+}
+
+// tarjan computes the Minimum Spanning Arborescence of d rooted at root
+// It returns the index of the arc going into every node (-1 for the root), or false if no spanning arborescence exists
+// If dt isn't nil, the dual variables are recorded in it
+func (d *digraph) tarjan(root int, dt *dualTree) ([]int, bool) {
+	n := len(d.nodes)
+	uf := newRollbackUnionFind(n)
+	if dt != nil {
+		dt.init(n)
+	}
+
+	// Fill the heap of incoming arcs of every node
+	heaps := make([]*heapNode, n)
+	for i, a := range d.arcs {
+		if a.to == root {
+			continue
+		}
+		heaps[a.to] = mergeHeaps(heaps[a.to], &heapNode{arc: i, key: a.weight, rank: 1})
+	}
+
+	// seen[v] is the starting node of the walk that reached v, -1 if none did
+	seen := make([]int, n)
+	for i := range seen {
+		seen[i] = -1
+	}
+	seen[root] = root
+	in := make([]int, n)
+	for i := range in {
+		in[i] = -1
+	}
+
+	var (
+		path         []int // supernodes of the current walk
+		queue        []int // arcs chosen along the current walk
+		contractions []contraction
+	)
+	for s := 0; s < n; s++ {
+		path, queue = path[:0], queue[:0]
+		u := s
+		// Walk backwards along the lightest incoming arcs until reaching a node already attached to the root
+		for seen[u] < 0 {
+			// Discard the arcs that became internal to u
+			for heaps[u] != nil {
+				heaps[u].push()
+				if uf.find(d.arcs[heaps[u].arc].from) != u {
+					break
+				}
+				heaps[u] = popHeap(heaps[u])
+			}
+			if heaps[u] == nil {
+				return nil, false
+			}
+
+			// Select the lightest arc, and reduce the weight of the other ones accordingly
+			top := heaps[u]
+			heaps[u] = popHeap(top)
+			if heaps[u] != nil {
+				heaps[u].delta -= top.key
+			}
+			if dt != nil {
+				dt.value[dt.set[u]] = top.key
+			}
+			queue = append(queue, top.arc)
+			path = append(path, u)
+			seen[u] = s
+
+			u = uf.find(d.arcs[top.arc].from)
+			if seen[u] != s {
+				continue
+			}
+
+			// We found a cycle, contract it
+			var (
+				cycleHeap *heapNode
+				end       = len(queue)
+				t         = uf.time()
+				w         int
+			)
+			var children []int
+			for {
+				w, path = path[len(path)-1], path[:len(path)-1]
+				cycleHeap = mergeHeaps(cycleHeap, heaps[w])
+				if dt != nil {
+					children = append(children, dt.set[w])
+				}
+				if !uf.union(u, w) {
+					break
+				}
+			}
+			start := len(path)
+			cycle := make([]int, end-start)
+			copy(cycle, queue[start:end])
+			queue = queue[:start]
+
+			u = uf.find(u)
+			if dt != nil {
+				dt.contract(u, children)
+			}
+			heaps[u] = cycleHeap
+			seen[u] = -1
+			contractions = append(contractions, contraction{node: u, time: t, arcs: cycle})
+		}
+
+		for _, a := range queue {
+			in[uf.find(d.arcs[a].to)] = a
+		}
+	}
+
+	// Expand the cycles, the most recent first
+	for i := len(contractions) - 1; i >= 0; i-- {
+		c := contractions[i]
+		uf.rollback(c.time)
+		entering := in[c.node]
+		for _, a := range c.arcs {
+			in[uf.find(d.arcs[a].to)] = a
+		}
+		in[uf.find(d.arcs[entering].to)] = entering
+	}
+
+	in[root] = -1
+	return in, true
+}
@@ -0,0 +1,89 @@
+package msa
+
+import (
+	"github.com/gyuho/goraph"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func TestSolve_Tarjan(t *testing.T) {
+	tests := []struct {
+		graphID string
+		root    string
+		weight  float64
+	}{
+		{"graph_17", "A", 26},
+		{"graph_17", "B", 21},
+		{"graph_17", "C", 23},
+		{"graph_17", "D", 15},
+		{"graph_00", "S", 75},
+		{"graph_10", "T", 74},
+		{"graph_12", "B", -13},
+	}
+
+	for _, test := range tests {
+		g := loadGraph(t, test.graphID)
+		arb, err := Solve(g, goraph.StringID(test.root), WithAlgorithm(Tarjan))
+		if err != nil {
+			t.Errorf("%s rooted at %s: unexpected error: %v", test.graphID, test.root, err)
+			continue
+		}
+		if arb.Weight != test.weight {
+			t.Errorf("%s rooted at %s: expected weight %v, got %v", test.graphID, test.root, test.weight, arb.Weight)
+		}
+		if len(arb.Parent) != g.GetNodeCount()-1 {
+			t.Errorf("%s rooted at %s: expected %d parents, got %d", test.graphID, test.root, g.GetNodeCount()-1, len(arb.Parent))
+		}
+	}
+}
+
+func TestSolve_TarjanInfeasible(t *testing.T) {
+	g := loadGraph(t, "graph_05")
+	if _, err := Solve(g, goraph.StringID("A"), WithAlgorithm(Tarjan)); err == nil {
+		t.Error("Expected an error for an infeasible graph")
+	}
+}
+
+// randomGraph generates a graph of n nodes where every node is reachable from node "0", with about m edges
+func randomGraph(r *rand.Rand, n int, m int) goraph.Graph {
+	g := goraph.NewGraph()
+	for i := 0; i < n; i++ {
+		g.AddNode(goraph.NewNode(strconv.Itoa(i)))
+	}
+	// A random spanning tree first, to guarantee feasibility
+	for i := 1; i < n; i++ {
+		g.ReplaceEdge(goraph.StringID(strconv.Itoa(r.Intn(i))), goraph.StringID(strconv.Itoa(i)), float64(r.Intn(1000)))
+	}
+	for i := n - 1; i < m; i++ {
+		g.ReplaceEdge(goraph.StringID(strconv.Itoa(r.Intn(n))), goraph.StringID(strconv.Itoa(r.Intn(n))), float64(r.Intn(1000)))
+	}
+	return g
+}
+
+func TestSolve_TarjanLarge(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	const n = 20000
+	g := randomGraph(rand.New(rand.NewSource(42)), n, 10*n)
+	arb, err := Solve(g, goraph.StringID("0"), WithAlgorithm(Tarjan))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(arb.Parent) != n-1 {
+		t.Fatalf("Expected %d parents, got %d", n-1, len(arb.Parent))
+	}
+
+	// Every node must lead back to the root
+	for i := 0; i < n; i++ {
+		id := goraph.ID(goraph.StringID(strconv.Itoa(i)))
+		for steps := 0; id.String() != "0"; steps++ {
+			if steps == n {
+				t.Fatalf("Node %d isn't attached to the root", i)
+			}
+			id = arb.Parent[id].Source().ID()
+		}
+	}
+}
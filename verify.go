@@ -0,0 +1,304 @@
+package msa
+
+import (
+	"fmt"
+	"github.com/gyuho/goraph"
+	"math"
+	"sort"
+)
+
+// This file implements the certification of arborescences through linear programming duality
+// The minimum spanning arborescence rooted at r is the optimum of the linear program:
+//	minimize Σ w(e)·x(e) such that Σ x(e) ≥ 1 over the edges e going into S, for every set S of nodes not containing r, with x ≥ 0
+// whose dual is:
+//	maximize Σ y(S) such that Σ y(S) ≤ w(e) over the sets S that e goes into, for every edge e, with y ≥ 0
+// As every node but the root has exactly one incoming edge, the constraints of single nodes can be made equalities, so that their dual variables may be negative.
+// The weight of any arborescence is at least Σ y(S) for a feasible y, so one whose weight equals it is optimal.
+// The contraction of Chu–Liu/Edmonds' algorithm yields such a y: the reduced weight of the edge chosen for every (super)node.
+// See J. Edmonds, "Optimum branchings", Journal of Research of the National Bureau of Standards, 1967
+
+// Dual is a dual variable, associated with a set of nodes not containing the root
+type Dual struct {
+	// Nodes lists the IDs of the nodes of the set
+	Nodes []goraph.ID
+
+	// Value is the value of the variable
+	Value float64
+}
+
+// Verify checks that tree is a spanning arborescence of g rooted at root, made of edges of g with their weights, and respecting the constraints set by WithRequired and WithForbidden.
+// If tree has dual variables, as returned by Solve when given WithDuals, it also checks that they certify that tree is of minimum weight,
+// or of maximum weight with WithObjective(Maximize).
+// It returns nil if so, and an error describing the first problem found otherwise.
+func Verify(g goraph.Graph, root goraph.ID, tree *Arborescence, opts ...Option) error {
+	if err := verify(Goraph(g), root, tree, newConfig(opts)); err != nil {
+		return fmt.Errorf("Verify: %v", err)
+	}
+	return nil
+}
+
+// VerifyGraph is like Verify, but works on any Graph
+// Of several edges going from a node to another, every edge of tree must match one by weight, as well as by ID and label if it has them.
+func VerifyGraph(g Graph, root goraph.ID, tree *Arborescence, opts ...Option) error {
+	if err := verify(g, root, tree, newConfig(opts)); err != nil {
+		return fmt.Errorf("VerifyGraph: %v", err)
+	}
+	return nil
+}
+
+// verify implements Verify and VerifyGraph
+func verify(g Graph, root goraph.ID, tree *Arborescence, c *config) error {
+	d, r, err := newConstrainedDigraph(g, root, c)
+	if err != nil {
+		return err
+	}
+	if tree.Root == nil || tree.Root.String() != root.String() {
+		return fmt.Errorf("the tree is rooted at %v instead of %s", tree.Root, root.String())
+	}
+
+	arcs := make(map[[2]int][]int, len(d.arcs))
+	var tolerance float64
+	for i, a := range d.arcs {
+		arcs[[2]int{a.from, a.to}] = append(arcs[[2]int{a.from, a.to}], i)
+		tolerance += math.Abs(a.weight)
+	}
+	tolerance = 1e-9 * (1 + tolerance)
+
+	// Every edge must be one of the graph, and every node but the root must have exactly one
+	in := make([]int, len(d.nodes))
+	for i := range in {
+		in[i] = -1
+	}
+	var weight float64
+	for _, e := range tree.Edges {
+		u, okSource := d.index[e.Source().ID().String()]
+		v, okTarget := d.index[e.Target().ID().String()]
+		parallel := arcs[[2]int{u, v}]
+		if !okSource || !okTarget || len(parallel) == 0 {
+			return fmt.Errorf("edge %s isn't in the graph, or is forbidden", e.String())
+		}
+		a := d.match(parallel, e)
+		if a < 0 {
+			return fmt.Errorf("edge %s has weight %v in the graph", e.String(), d.arcs[parallel[0]].weight)
+		}
+		if in[v] >= 0 {
+			return fmt.Errorf("node %s has several incoming edges", e.Target().ID().String())
+		}
+		if parent, ok := tree.Parent[e.Target().ID()]; !ok || parent.Source().ID().String() != e.Source().ID().String() {
+			return fmt.Errorf("the parent of node %s doesn't match its incoming edge", e.Target().ID().String())
+		}
+		in[v] = a
+		weight += e.Weight()
+	}
+	for v := range d.nodes {
+		if v != r && in[v] < 0 {
+			return fmt.Errorf("node %s has no incoming edge", d.nodes[v].ID().String())
+		}
+	}
+	if len(tree.Parent) != len(tree.Edges) {
+		return fmt.Errorf("the tree has %d parents but %d edges", len(tree.Parent), len(tree.Edges))
+	}
+
+	// Every node must lead back to the root
+	const (
+		unvisited = iota
+		visiting
+		attached
+	)
+	state := make([]int, len(d.nodes))
+	state[r] = attached
+	var path []int
+	for v := range d.nodes {
+		path = path[:0]
+		u := v
+		for state[u] == unvisited {
+			state[u] = visiting
+			path = append(path, u)
+			u = d.arcs[in[u]].from
+		}
+		if state[u] == visiting {
+			return fmt.Errorf("node %s is part of a cycle", d.nodes[u].ID().String())
+		}
+		for _, w := range path {
+			state[w] = attached
+		}
+	}
+
+	if math.Abs(tree.Weight-weight) > tolerance {
+		return fmt.Errorf("the tree has weight %v, but its edges sum to %v", tree.Weight, weight)
+	}
+
+	if tree.Duals == nil {
+		return nil
+	}
+	return verifyDuals(d, r, tree.Duals, c.sign(), weight, tolerance)
+}
+
+// match returns the arc among parallel ones that e stands for, that is one whose edge has the same weight, as well as the same ID and label if e has them, or -1 if there is none
+func (d *digraph) match(parallel []int, e goraph.Edge) int {
+	ie, identified := e.(IdentifiedEdge)
+	le, labeled := e.(LabeledEdge)
+	for _, a := range parallel {
+		edge := d.edge(a)
+		if edge.Weight() != e.Weight() {
+			continue
+		}
+		if identified {
+			if ia, ok := edge.(IdentifiedEdge); !ok || ia.EdgeID() != ie.EdgeID() {
+				continue
+			}
+		}
+		if labeled {
+			if la, ok := edge.(LabeledEdge); !ok || la.Label() != le.Label() {
+				continue
+			}
+		}
+		return a
+	}
+	return -1
+}
+
+// verifyDuals checks that duals is a feasible solution of the dual of the arborescence problem on d, whose objective is weight
+// The sets must be laminar, that is any two of them are either disjoint or one contains the other
+func verifyDuals(d *digraph, root int, duals []Dual, sign float64, weight float64, tolerance float64) error {
+	// Process the sets from the largest to the smallest, so that every one comes after those containing it
+	order := make([]int, len(duals))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Stable(dualsBySize{order, duals})
+
+	// inner is the smallest set containing every node so far, and cumulated the sum of the values of a set and of those containing it
+	inner := make([]int, len(d.nodes))
+	for i := range inner {
+		inner[i] = -1
+	}
+	parent := make([]int, len(duals))
+	depth := make([]int, len(duals))
+	cumulated := make([]float64, len(duals))
+	var total float64
+	for _, k := range order {
+		dual := duals[k]
+		if len(dual.Nodes) == 0 {
+			return fmt.Errorf("dual variable %d has no nodes", k)
+		}
+		parent[k] = -2
+		for _, id := range dual.Nodes {
+			v, ok := d.index[id.String()]
+			if !ok {
+				return fmt.Errorf("dual variable %d contains node %s, which isn't in the graph", k, id.String())
+			}
+			if v == root {
+				return fmt.Errorf("dual variable %d contains the root", k)
+			}
+			if parent[k] == -2 {
+				parent[k] = inner[v]
+			} else if inner[v] != parent[k] {
+				return fmt.Errorf("the sets of the dual variables aren't laminar")
+			}
+		}
+		for _, id := range dual.Nodes {
+			inner[d.index[id.String()]] = k
+		}
+
+		value := sign * dual.Value
+		if len(dual.Nodes) > 1 && value < -tolerance {
+			return fmt.Errorf("dual variable %d has value %v, which is infeasible for a set of several nodes", k, dual.Value)
+		}
+		cumulated[k] = value
+		if p := parent[k]; p >= 0 {
+			cumulated[k] += cumulated[p]
+			depth[k] = depth[p] + 1
+		}
+		total += value
+	}
+
+	// The dual variables of the sets an arc goes into must sum to at most its weight
+	// These are the ancestors of the smallest set containing its target that don't contain its source
+	for i, a := range d.arcs {
+		if a.to == root {
+			continue
+		}
+		s, t := inner[a.from], inner[a.to]
+		sum := 0.0
+		if t >= 0 {
+			sum = cumulated[t]
+		}
+		for s != t {
+			if s < 0 || (t >= 0 && depth[t] > depth[s]) {
+				t = parent[t]
+			} else {
+				s = parent[s]
+			}
+		}
+		if s >= 0 {
+			sum -= cumulated[s]
+		}
+		if sum > sign*a.weight+tolerance {
+			return fmt.Errorf("the dual variables are infeasible for edge %s", d.edge(i).String())
+		}
+	}
+
+	if math.Abs(total-sign*weight) > tolerance {
+		return fmt.Errorf("the dual variables sum to %v instead of the weight of the tree %v, which isn't proven optimal", sign*total, weight)
+	}
+	return nil
+}
+
+// dualsBySize sorts indexes of duals by decreasing size of their set
+type dualsBySize struct {
+	order []int
+	duals []Dual
+}
+
+func (s dualsBySize) Len() int { return len(s.order) }
+func (s dualsBySize) Less(i, j int) bool {
+	return len(s.duals[s.order[i]].Nodes) > len(s.duals[s.order[j]].Nodes)
+}
+func (s dualsBySize) Swap(i, j int) { s.order[i], s.order[j] = s.order[j], s.order[i] }
+
+// dualTree records the dual variables computed by tarjan, whose sets are laminar
+// Sets 0 to n-1 are the single nodes, the following ones the contracted cycles
+type dualTree struct {
+	value    []float64
+	children [][]int // sets making up every contracted cycle, indexed by its set minus n
+	set      []int   // set represented by every union-find representative
+}
+
+func (dt *dualTree) init(n int) {
+	dt.value = make([]float64, n, 2*n)
+	dt.children = nil
+	dt.set = make([]int, n)
+	for i := range dt.set {
+		dt.set[i] = i
+	}
+}
+
+// contract records a cycle made of the given sets, represented by rep in the union-find
+func (dt *dualTree) contract(rep int, children []int) {
+	dt.set[rep] = len(dt.value)
+	dt.value = append(dt.value, 0)
+	dt.children = append(dt.children, children)
+}
+
+// duals returns the recorded dual variables, except the root's, their values being multiplied by sign
+func (dt *dualTree) duals(d *digraph, root int, sign float64) []Dual {
+	n := len(d.nodes)
+	members := make([][]goraph.ID, len(dt.value))
+	duals := make([]Dual, 0, len(dt.value)-1)
+	for s, value := range dt.value {
+		if s == root {
+			continue
+		}
+		if s < n {
+			members[s] = []goraph.ID{d.nodes[s].ID()}
+		} else {
+			for _, child := range dt.children[s-n] {
+				members[s] = append(members[s], members[child]...)
+			}
+			sort.Sort(idsByString(members[s]))
+		}
+		duals = append(duals, Dual{Nodes: members[s], Value: sign * value})
+	}
+	return duals
+}
@@ -0,0 +1,119 @@
+package msa
+
+import (
+	"fmt"
+	"github.com/gyuho/goraph"
+	"math/rand"
+	"testing"
+)
+
+// Test that the dual variables returned by Solve certify the arborescences of every graph, with every root
+func TestVerify_Duals(t *testing.T) {
+	for i := 0; i <= 17; i++ {
+		graphID := fmt.Sprintf("graph_%02d", i)
+		g := loadGraph(t, graphID)
+		for root := range g.GetNodes() {
+			for _, alg := range []Algorithm{Naive, Tarjan} {
+				for _, obj := range []Objective{Minimize, Maximize} {
+					arb, err := Solve(g, root, WithAlgorithm(alg), WithObjective(obj), WithDuals())
+					if _, ok := err.(*InfeasibleError); ok {
+						continue
+					}
+					if err != nil {
+						t.Fatalf("%s rooted at %s: %v", graphID, root, err)
+					}
+					if arb.Duals == nil {
+						t.Fatalf("%s rooted at %s: no dual variables were returned", graphID, root)
+					}
+					if err := Verify(g, root, arb, WithObjective(obj)); err != nil {
+						t.Errorf("%s rooted at %s, %v with %v: %v", graphID, root, obj, alg, err)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestVerify_Random(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	for i := 0; i < 50; i++ {
+		g := randomGraph(r, 30, 150)
+		arb, err := Solve(g, goraph.StringID("0"), WithAlgorithm(Tarjan), WithDuals())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Verify(g, goraph.StringID("0"), arb); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestVerify_Constraints(t *testing.T) {
+	g := loadGraph(t, "graph_17")
+	root := goraph.StringID("D")
+	best, err := Solve(g, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forbidden := WithForbidden(testEdge("A", "B"))
+
+	arb, err := Solve(g, root, forbidden, WithDuals())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(g, root, arb, forbidden); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := Verify(g, root, best, forbidden); err == nil {
+		t.Error("Expected an error for a tree using a forbidden edge")
+	}
+}
+
+// Test that suboptimal and invalid trees are rejected
+func TestVerify_Rejects(t *testing.T) {
+	g := loadGraph(t, "graph_17")
+	root := goraph.StringID("D")
+	arbs, err := KBest(g, root, 2, WithDuals())
+	if err != nil {
+		t.Fatal(err)
+	}
+	best, second := arbs[0], arbs[1]
+	if err := Verify(g, root, best); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	suboptimal := *second
+	suboptimal.Duals = best.Duals
+	if err := Verify(g, root, &suboptimal); err == nil {
+		t.Error("Expected an error for a suboptimal tree")
+	}
+
+	// Without dual variables, only the structure is checked
+	suboptimal.Duals = nil
+	if err := Verify(g, root, &suboptimal); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	missing := *best
+	missing.Edges = missing.Edges[1:]
+	if err := Verify(g, root, &missing); err == nil {
+		t.Error("Expected an error for a tree missing an edge")
+	}
+
+	if err := Verify(g, goraph.StringID("A"), best); err == nil {
+		t.Error("Expected an error for a tree with another root")
+	}
+
+	wrongWeight := *best
+	wrongWeight.Weight--
+	if err := Verify(g, root, &wrongWeight); err == nil {
+		t.Error("Expected an error for a tree with a wrong weight")
+	}
+
+	infeasible := *best
+	infeasible.Duals = append([]Dual(nil), best.Duals...)
+	infeasible.Duals[0].Value += 1
+	if err := Verify(g, root, &infeasible); err == nil {
+		t.Error("Expected an error for infeasible dual variables")
+	}
+}